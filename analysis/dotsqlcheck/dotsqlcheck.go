@@ -0,0 +1,128 @@
+// Package dotsqlcheck defines an Analyzer that checks the query names and
+// argument counts of calls to dotsql.
+//
+// # Analyzer dotsqlcheck
+//
+// dotsqlcheck: check dotsql query names and arguments
+//
+// The analyzer loads the queries of every file a package passes to
+// dotsql.LoadFromFile, LoadFromString, LoadFromFS, LoadFromFSWith or
+// LoadFromDir with arguments known at compile time: a constant path, or a
+// variable declared with a //go:embed directive. Paths are resolved relative
+// to the package directory. It then reports the calls to DotSql methods and
+// to the generic helpers, such as Select, that name a query that was not
+// loaded, or that pass a number of arguments not matching the placeholders
+// of the query.
+//
+// Only calls on a variable assigned the result of one of these functions,
+// and never assigned anything else, are checked, against the queries loaded
+// by that call. A DotSql received as a parameter, built by Merge or loaded
+// from a path known only at run time could hold any query.
+//
+// Queries using templates, named parameters or :1 placeholders, and calls
+// passing a slice with ... or a sql.NamedArg, are not checked for arguments.
+package dotsqlcheck
+
+import (
+	"go/ast"
+	"go/types"
+	"text/template/parse"
+
+	"github.com/qustavo/dotsql"
+	"github.com/qustavo/dotsql/internal/calls"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "dotsqlcheck",
+	Doc:      "check dotsql query names and arguments",
+	URL:      "https://pkg.go.dev/github.com/qustavo/dotsql/analysis/dotsqlcheck",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	if !imports(pass.Pkg, calls.DotsqlPath) {
+		return nil, nil
+	}
+
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	l := newLoader(pass)
+	inspect.Preorder([]ast.Node{(*ast.AssignStmt)(nil), (*ast.ValueSpec)(nil)}, func(n ast.Node) {
+		l.assign(n)
+	})
+	if len(l.dots) == 0 {
+		return nil, nil
+	}
+
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		check(pass, l, n.(*ast.CallExpr))
+	})
+	return nil, nil
+}
+
+// check reports the problems of call, if it runs a query.
+func check(pass *analysis.Pass, l *loader, expr *ast.CallExpr) {
+	name, c, ok := calls.QueryName(pass.TypesInfo, expr)
+	if !ok {
+		return
+	}
+
+	dot := l.dotSql(c.DotSql(expr))
+	if dot == nil {
+		return
+	}
+	if _, ok := dot.QueryMap()[name]; !ok {
+		pass.Reportf(expr.Args[c.Name].Pos(), "unknown query %q", name)
+		return
+	}
+
+	if c.Args < 0 || expr.Ellipsis.IsValid() || isTemplate(dot, name) {
+		return
+	}
+	args := expr.Args[c.Args:]
+	for _, arg := range args {
+		if isNamedArg(pass.TypesInfo.TypeOf(arg)) {
+			return
+		}
+	}
+	if want := dot.NumInput(name); want >= 0 && want != len(args) {
+		pass.Reportf(expr.Pos(), "query %q expects %d args, got %d", name, want, len(args))
+	}
+}
+
+func isNamedArg(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "database/sql" && obj.Name() == "NamedArg"
+}
+
+// isTemplate reports whether the query has template actions, whose output,
+// and so the number of placeholders, depends on data.
+func isTemplate(dot *dotsql.DotSql, name string) bool {
+	tmpl := dot.QueryMap()[name]
+	if tmpl == nil || tmpl.Tree == nil {
+		return false
+	}
+	for _, node := range tmpl.Tree.Root.Nodes {
+		if node.Type() != parse.NodeText {
+			return true
+		}
+	}
+	return false
+}
+
+func imports(pkg *types.Package, path string) bool {
+	for _, imp := range pkg.Imports() {
+		if imp.Path() == path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+package dotsqlcheck_test
+
+import (
+	"testing"
+
+	"github.com/qustavo/dotsql/analysis/dotsqlcheck"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), dotsqlcheck.Analyzer, "a", "b")
+}
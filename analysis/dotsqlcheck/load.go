@@ -0,0 +1,430 @@
+package dotsqlcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/qustavo/dotsql"
+	"github.com/qustavo/dotsql/internal/calls"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// loader collects the queries loaded by a package into its variables.
+type loader struct {
+	pass *analysis.Pass
+	// embeds holds the patterns of the variables declared with a //go:embed
+	// directive.
+	embeds map[types.Object][]string
+	// dots maps the variables assigned the result of a loading function to
+	// the DotSql it returns, nil if it could not be loaded.
+	dots map[types.Object]*dotsql.DotSql
+	// assigns counts the assignments to every variable.
+	assigns map[types.Object]int
+}
+
+func newLoader(pass *analysis.Pass) *loader {
+	l := &loader{
+		pass:    pass,
+		embeds:  make(map[types.Object][]string),
+		dots:    make(map[types.Object]*dotsql.DotSql),
+		assigns: make(map[types.Object]int),
+	}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if decl, ok := decl.(*ast.GenDecl); ok {
+				l.collectEmbeds(decl)
+			}
+		}
+	}
+	return l
+}
+
+// collectEmbeds records the //go:embed patterns of the variables of decl.
+func (l *loader) collectEmbeds(decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		spec, ok := spec.(*ast.ValueSpec)
+		if !ok || len(spec.Names) != 1 {
+			continue
+		}
+		doc := spec.Doc
+		if doc == nil && len(decl.Specs) == 1 {
+			doc = decl.Doc
+		}
+		if patterns := embedPatterns(doc); len(patterns) > 0 {
+			l.embeds[l.pass.TypesInfo.Defs[spec.Names[0]]] = patterns
+		}
+	}
+}
+
+// embedPatterns returns the patterns of the //go:embed directives of doc.
+func embedPatterns(doc *ast.CommentGroup) []string {
+	if doc == nil {
+		return nil
+	}
+
+	var patterns []string
+	for _, comment := range doc.List {
+		args, ok := strings.CutPrefix(comment.Text, "//go:embed ")
+		if !ok {
+			continue
+		}
+		for _, arg := range strings.Fields(args) {
+			if unquoted, err := strconv.Unquote(arg); err == nil {
+				arg = unquoted
+			}
+			patterns = append(patterns, arg)
+		}
+	}
+	return patterns
+}
+
+// assign records the assignments of n, an *ast.AssignStmt or *ast.ValueSpec,
+// and the DotSql assigned to a variable by a call to a dotsql loading
+// function.
+func (l *loader) assign(n ast.Node) {
+	var lhs, rhs []ast.Expr
+	switch n := n.(type) {
+	case *ast.AssignStmt:
+		lhs, rhs = n.Lhs, n.Rhs
+	case *ast.ValueSpec:
+		if len(n.Values) == 0 {
+			return
+		}
+		for _, name := range n.Names {
+			lhs = append(lhs, name)
+		}
+		rhs = n.Values
+	}
+
+	for _, expr := range lhs {
+		if obj := l.object(expr); obj != nil {
+			l.assigns[obj]++
+		}
+	}
+	if len(lhs) != 2 || len(rhs) != 1 {
+		return
+	}
+	expr, ok := ast.Unparen(rhs[0]).(*ast.CallExpr)
+	if !ok {
+		return
+	}
+	if dot, ok := l.load(expr); ok {
+		if obj := l.object(lhs[0]); obj != nil {
+			l.dots[obj] = dot
+		}
+	}
+}
+
+// object returns the variable expr refers to, if it is an identifier.
+func (l *loader) object(expr ast.Expr) types.Object {
+	id, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	obj, ok := l.pass.TypesInfo.ObjectOf(id).(*types.Var)
+	if !ok {
+		return nil
+	}
+	return obj
+}
+
+// dotSql returns the DotSql expr evaluates to, if known: expr is a variable
+// only ever assigned the result of a loading function, or a copy of one
+// returned by a DotSql method such as WithData.
+func (l *loader) dotSql(expr ast.Expr) *dotsql.DotSql {
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		obj := l.object(expr)
+		if obj == nil || l.assigns[obj] != 1 {
+			return nil
+		}
+		return l.dots[obj]
+	case *ast.StarExpr:
+		return l.dotSql(expr.X)
+	case *ast.UnaryExpr:
+		if expr.Op == token.AND {
+			return l.dotSql(expr.X)
+		}
+	case *ast.CallExpr:
+		sel, ok := ast.Unparen(expr.Fun).(*ast.SelectorExpr)
+		if !ok {
+			return nil
+		}
+		fn, ok := typeutil.Callee(l.pass.TypesInfo, expr).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != calls.DotsqlPath {
+			return nil
+		}
+		sig := fn.Type().(*types.Signature)
+		if sig.Recv() == nil || sig.Results().Len() != 1 || !calls.IsDotSql(sig.Results().At(0).Type()) {
+			return nil
+		}
+		return l.dotSql(sel.X)
+	}
+	return nil
+}
+
+// load returns the queries loaded by expr, if it calls a dotsql loading
+// function. The DotSql is nil if they cannot be known at compile time.
+func (l *loader) load(expr *ast.CallExpr) (*dotsql.DotSql, bool) {
+	fn, ok := typeutil.Callee(l.pass.TypesInfo, expr).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != calls.DotsqlPath || fn.Type().(*types.Signature).Recv() != nil {
+		return nil, false
+	}
+
+	var dot *dotsql.DotSql
+	var err error
+	dir := filepath.Dir(l.pass.Fset.File(expr.Pos()).Name())
+
+	switch fn.Name() {
+	case "LoadFromFile":
+		file, ok := l.stringArg(expr, 0)
+		opts, optsOK := l.options(expr.Args[1:])
+		if !ok || !optsOK || expr.Ellipsis.IsValid() {
+			break
+		}
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		dot, err = dotsql.LoadFromFile(file, opts...)
+	case "LoadFromString":
+		sql, ok := l.stringArg(expr, 0)
+		opts, optsOK := l.options(expr.Args[1:])
+		if !ok || !optsOK || expr.Ellipsis.IsValid() {
+			break
+		}
+		dot, err = dotsql.LoadFromString(sql, opts...)
+	case "LoadFromFS":
+		fsys, ok := l.embedFS(expr.Args[0], dir)
+		patterns, patternsOK := l.stringArgs(expr.Args[1:])
+		if !ok || !patternsOK || expr.Ellipsis.IsValid() {
+			break
+		}
+		dot, err = dotsql.LoadFromFS(fsys, patterns...)
+	case "LoadFromFSWith":
+		fsys, ok := l.embedFS(expr.Args[0], dir)
+		opts, optsOK := l.optionsArg(expr.Args[1])
+		patterns, patternsOK := l.stringArgs(expr.Args[2:])
+		if !ok || !optsOK || !patternsOK || expr.Ellipsis.IsValid() {
+			break
+		}
+		dot, err = dotsql.LoadFromFSWith(fsys, opts, patterns...)
+	case "LoadFromDir":
+		fsys, ok := l.embedFS(expr.Args[0], dir)
+		root, rootOK := l.stringArg(expr, 1)
+		opts, optsOK := l.options(expr.Args[2:])
+		if !ok || !rootOK || !optsOK || expr.Ellipsis.IsValid() {
+			break
+		}
+		dot, err = dotsql.LoadFromDir(fsys, root, opts...)
+	case "Load":
+	default:
+		return nil, false
+	}
+
+	if err != nil {
+		return nil, true
+	}
+	return dot, true
+}
+
+// stringArg returns the i-th argument of expr, if it is a constant string.
+func (l *loader) stringArg(expr *ast.CallExpr, i int) (string, bool) {
+	if i >= len(expr.Args) {
+		return "", false
+	}
+	value := l.pass.TypesInfo.Types[expr.Args[i]].Value
+	if value == nil || value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(value), true
+}
+
+// stringArgs returns the values of args, if they are all constant strings.
+func (l *loader) stringArgs(args []ast.Expr) ([]string, bool) {
+	var values []string
+	for _, arg := range args {
+		value := l.pass.TypesInfo.Types[arg].Value
+		if value == nil || value.Kind() != constant.String {
+			return nil, false
+		}
+		values = append(values, constant.StringVal(value))
+	}
+	return values, true
+}
+
+// optionsArg is like options for the options of expr, which must be nil or a
+// []Option literal.
+func (l *loader) optionsArg(expr ast.Expr) ([]dotsql.Option, bool) {
+	expr = ast.Unparen(expr)
+	if l.pass.TypesInfo.Types[expr].IsNil() {
+		return nil, true
+	}
+	lit, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	return l.options(lit.Elts)
+}
+
+// options returns the loading options given by args. The boolean is false if
+// one of them changes which queries are loaded, or how they are named, in a
+// way that is not known at compile time. Options not affecting query names
+// are left out.
+func (l *loader) options(args []ast.Expr) ([]dotsql.Option, bool) {
+	var opts []dotsql.Option
+	for _, arg := range args {
+		expr, ok := ast.Unparen(arg).(*ast.CallExpr)
+		if !ok {
+			return nil, false
+		}
+		fn, ok := typeutil.Callee(l.pass.TypesInfo, expr).(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != calls.DotsqlPath {
+			return nil, false
+		}
+
+		switch fn.Name() {
+		case "Strict", "WithDuplicates", "PreserveFormatting", "WithBindStyle":
+		case "BackslashEscapes":
+			opts = append(opts, dotsql.BackslashEscapes())
+		case "WithSeparator":
+			sep, ok := l.stringArg(expr, 0)
+			if !ok {
+				return nil, false
+			}
+			opts = append(opts, dotsql.WithSeparator(sep))
+		case "WithTagParser":
+			tags, ok := l.tagParser(expr.Args[0])
+			if !ok {
+				return nil, false
+			}
+			opts = append(opts, dotsql.WithTagParser(tags))
+		default:
+			return nil, false
+		}
+	}
+	return opts, true
+}
+
+// tagParser returns the TagParser of dotsql referred to by expr.
+func (l *loader) tagParser(expr ast.Expr) (dotsql.TagParser, bool) {
+	var id *ast.Ident
+	switch expr := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		id = expr
+	case *ast.SelectorExpr:
+		id = expr.Sel
+	default:
+		return nil, false
+	}
+
+	obj := l.pass.TypesInfo.Uses[id]
+	if obj == nil || obj.Pkg() == nil || obj.Pkg().Path() != calls.DotsqlPath {
+		return nil, false
+	}
+	switch obj.Name() {
+	case "DefaultTags":
+		return dotsql.DefaultTags, true
+	case "YesqlTags":
+		return dotsql.YesqlTags, true
+	case "HugSQLTags":
+		return dotsql.HugSQLTags, true
+	}
+	return nil, false
+}
+
+// embedFS returns the files embedded in the variable referred to by expr,
+// read from dir.
+func (l *loader) embedFS(expr ast.Expr, dir string) (fs.FS, bool) {
+	id, ok := ast.Unparen(expr).(*ast.Ident)
+	if !ok {
+		return nil, false
+	}
+	patterns, ok := l.embeds[l.pass.TypesInfo.Uses[id]]
+	if !ok {
+		return nil, false
+	}
+
+	fsys := os.DirFS(dir)
+	files := make(map[string]bool)
+	for _, pattern := range patterns {
+		all := strings.HasPrefix(pattern, "all:")
+		matches, err := fs.Glob(fsys, strings.TrimPrefix(pattern, "all:"))
+		if err != nil {
+			return nil, false
+		}
+		for _, match := range matches {
+			err := fs.WalkDir(fsys, match, func(name string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				base := path.Base(name)
+				if name != match && !all && (strings.HasPrefix(base, ".") || strings.HasPrefix(base, "_")) {
+					if entry.IsDir() {
+						return fs.SkipDir
+					}
+					return nil
+				}
+				if !entry.IsDir() {
+					files[name] = true
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, false
+			}
+		}
+	}
+	return embedded{fsys, files}, true
+}
+
+// embedded is the subset of the files of an fs.FS embedded by //go:embed
+// directives.
+type embedded struct {
+	fsys  fs.FS
+	files map[string]bool
+}
+
+func (e embedded) Open(name string) (fs.File, error) {
+	if !e.exists(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return e.fsys.Open(name)
+}
+
+func (e embedded) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(e.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		if e.exists(path.Join(name, entry.Name())) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+// exists reports whether name is an embedded file or one of their
+// directories.
+func (e embedded) exists(name string) bool {
+	if name == "." || e.files[name] {
+		return true
+	}
+	for file := range e.files {
+		if strings.HasPrefix(file, name+"/") {
+			return true
+		}
+	}
+	return false
+}
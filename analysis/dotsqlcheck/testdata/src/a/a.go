@@ -0,0 +1,61 @@
+package a
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+
+	"github.com/qustavo/dotsql"
+)
+
+//go:embed sql
+var files embed.FS
+
+const findUsers = "find-users"
+
+func queries(ctx context.Context, db *sql.DB, args []any) {
+	dot, _ := dotsql.LoadFromFile("queries.sql", dotsql.Strict())
+	fsDot, _ := dotsql.LoadFromFSWith(files, []dotsql.Option{dotsql.Strict()}, "sql/*.sql")
+	allDot, _ := dotsql.LoadFromFS(files)
+	dirDot, _ := dotsql.LoadFromDir(files, "sql", dotsql.WithSeparator("/"))
+	strDot, _ := dotsql.LoadFromString("-- name: count-users\nSELECT count(*) FROM users WHERE id > ?")
+
+	dot.Exec(db, "create-user", "foo", "foo@bar.com")
+	dot.Exec(db, "create-user", "foo")   // want `query "create-user" expects 2 args, got 1`
+	dot.Exec(db, "create-usr", "foo")    // want `unknown query "create-usr"`
+	dot.Exec(db, "create-user", args...) // slices are not checked
+	dot.Query(db, findUsers)
+	dot.Query(db, findUsers, 1) // want `query "find-users" expects 0 args, got 1`
+	dot.Query(db, "find-users-by-name", sql.Named("name", "foo"))
+	dot.Query(db, "find-users-by-name", "foo") // named parameters are not checked
+	dot.ExecNamed(db, "find-users-by-name", map[string]any{"name": "foo"})
+	dot.WithData(map[string]any{"active": true}).Query(db, "find-users-filtered", true)
+	dot.Raw("find-user") // want `unknown query "find-user"`
+
+	fsDot.Exec(db, "delete-user", 1)
+	fsDot.Exec(db, "delete-user") // want `query "delete-user" expects 1 args, got 0`
+	allDot.Exec(db, "delete-roles")
+	allDot.Exec(db, "delete-rols") // want `unknown query "delete-rols"`
+	dirDot.Exec(db, "admin/delete-roles")
+	dirDot.Exec(db, "admin.delete-roles") // want `unknown query "admin.delete-roles"`
+
+	dotsql.Select[int](ctx, strDot, db, "count-users", 1)
+	dotsql.Select[int](ctx, strDot, db, "count-users") // want `query "count-users" expects 1 args, got 0`
+}
+
+// Only the queries loaded into the DotSql a method is called on are known.
+func shared(db *sql.DB, shared *dotsql.DotSql) {
+	shared.Exec(db, "create-user", "foo")
+	shared.Exec(db, "create-usr")
+
+	dot, _ := dotsql.LoadFromString("-- name: create-user\nINSERT INTO users (name) VALUES (?)")
+	other, _ := dotsql.LoadFromString("-- name: create-user\nINSERT INTO users (name, email) VALUES (?, ?)")
+	dot.Exec(db, "create-user", "foo")
+	dot.Exec(db, "create-user", "foo", "foo@bar.com") // want `query "create-user" expects 1 args, got 2`
+	other.Exec(db, "create-user", "foo", "foo@bar.com")
+	(*dot).Exec(db, "create-usr") // want `unknown query "create-usr"`
+
+	reassigned, _ := dotsql.LoadFromString("-- name: create-user\nINSERT INTO users (name) VALUES (?)")
+	reassigned = shared
+	reassigned.Exec(db, "create-usr")
+}
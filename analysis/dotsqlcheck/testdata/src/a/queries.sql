@@ -0,0 +1,11 @@
+-- name: create-user
+INSERT INTO users (name, email) VALUES (?, ?)
+
+-- name: find-users
+SELECT * FROM users
+
+-- name: find-users-by-name
+SELECT * FROM users WHERE name = :name
+
+-- name: find-users-filtered
+SELECT * FROM users {{if .active}}WHERE active = ?{{end}}
@@ -0,0 +1,2 @@
+-- name: delete-roles
+DELETE FROM roles
@@ -0,0 +1,2 @@
+-- name: delete-user
+DELETE FROM users WHERE id = $1
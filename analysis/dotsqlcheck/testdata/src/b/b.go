@@ -0,0 +1,17 @@
+package b
+
+import (
+	"database/sql"
+	"os"
+
+	"github.com/qustavo/dotsql"
+)
+
+// Queries loaded from a file not known at compile time could have any name.
+func queries(db *sql.DB) {
+	dot, _ := dotsql.LoadFromFile("queries.sql")
+	other, _ := dotsql.LoadFromFile(os.Getenv("QUERIES"))
+
+	dot.Exec(db, "create-user", "foo") // want `query "create-user" expects 0 args, got 1`
+	other.Exec(db, "find-users")
+}
@@ -0,0 +1,2 @@
+-- name: create-user
+INSERT INTO users DEFAULT VALUES
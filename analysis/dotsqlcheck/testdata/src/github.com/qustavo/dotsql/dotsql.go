@@ -0,0 +1,42 @@
+// Package dotsql is a stub of the dotsql API, enough to type check the test
+// packages.
+package dotsql
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+)
+
+type DotSql struct{}
+
+type Option func()
+
+type TagParser func(string) (string, bool)
+
+type QueryerContext interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func LoadFromFile(file string, opts ...Option) (*DotSql, error)  { return nil, nil }
+func LoadFromString(sql string, opts ...Option) (*DotSql, error) { return nil, nil }
+func LoadFromFS(fsys fs.FS, patterns ...string) (*DotSql, error) { return nil, nil }
+func LoadFromFSWith(fsys fs.FS, opts []Option, patterns ...string) (*DotSql, error) {
+	return nil, nil
+}
+func LoadFromDir(fsys fs.FS, root string, opts ...Option) (*DotSql, error) { return nil, nil }
+
+func Strict() Option                       { return nil }
+func WithSeparator(sep string) Option      { return nil }
+func WithTagParser(p TagParser) Option     { return nil }
+func YesqlTags(line string) (string, bool) { return "", false }
+
+func (d DotSql) Exec(db any, name string, args ...any) (sql.Result, error)  { return nil, nil }
+func (d DotSql) Query(db any, name string, args ...any) (*sql.Rows, error)  { return nil, nil }
+func (d DotSql) ExecNamed(db any, name string, arg any) (sql.Result, error) { return nil, nil }
+func (d DotSql) Raw(name string) (string, error)                            { return "", nil }
+func (d DotSql) WithData(data any) DotSql                                   { return d }
+
+func Select[T any](ctx context.Context, dot *DotSql, db QueryerContext, name string, args ...any) ([]T, error) {
+	return nil, nil
+}
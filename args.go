@@ -0,0 +1,119 @@
+package dotsql
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ArgCountError is returned when a query is run with a number of arguments
+// not matching its placeholders.
+type ArgCountError struct {
+	Name string // query name
+	Want int    // number of placeholders
+	Got  int    // number of arguments
+}
+
+func (e *ArgCountError) Error() string {
+	return fmt.Sprintf("dotsql: %q expects %d args, got %d", e.Name, e.Want, e.Got)
+}
+
+// countPlaceholders returns the number of arguments query expects once
+// rebound to style, counting ? placeholders, and $1 or ?1 numbered ones by
+// their highest number. A doubled ?? is a literal ? unless style is
+// BindQuestion, which passes it to the driver as written. In queries using
+// $1 placeholders, a ? is taken as an operator, such as the PostgreSQL jsonb
+// ?, ?| and ?& ones. The boolean is false if the query uses named parameters
+// or :1 placeholders, whose count cannot be checked.
+func countPlaceholders(query string, style BindStyle, escapes bool) (int, bool) {
+	questions, numbered := 0, 0
+	dollars := false
+	for _, seg := range splitSQL(query, escapes) {
+		if !seg.code {
+			continue
+		}
+
+		text := seg.text
+		for i := 0; i < len(text); i++ {
+			switch {
+			case text[i] == '?' && isDigit(text, i+1):
+				i, numbered = placeholderNumber(text, i, numbered)
+			case text[i] == '?':
+				if i+1 < len(text) && text[i+1] == '?' && style != BindQuestion {
+					i++
+					continue
+				}
+				questions++
+			case text[i] == '$' && (i == 0 || !isIdentChar(text[i-1])):
+				if i+1 < len(text) && isIdentStart(text[i+1]) {
+					return 0, false
+				}
+				dollars = dollars || isDigit(text, i+1)
+				i, numbered = placeholderNumber(text, i, numbered)
+			case text[i] == ':' && isDigit(text, i+1) && (i == 0 || text[i-1] != ':' && !isIdentChar(text[i-1])):
+				return 0, false
+			case namedParameter(text, i) != "":
+				return 0, false
+			}
+		}
+	}
+
+	if dollars {
+		return numbered, true
+	}
+	return questions + numbered, true
+}
+
+// placeholderNumber reads the number following the placeholder prefix at
+// text[i], returning the index of its last digit and the greater of the
+// number and max.
+func placeholderNumber(text string, i, max int) (int, int) {
+	n := 0
+	for isDigit(text, i+1) {
+		n = n*10 + int(text[i+1]-'0')
+		i++
+	}
+	if n > max {
+		max = n
+	}
+	return i, max
+}
+
+func isDigit(text string, i int) bool {
+	return i < len(text) && '0' <= text[i] && text[i] <= '9'
+}
+
+// checkArgs returns an *ArgCountError if args do not match the placeholders
+// of the rendered query. Queries using named parameters, and arguments
+// passed as sql.NamedArg, are not checked.
+func checkArgs(name, query string, args []any, style BindStyle, escapes bool) error {
+	for _, arg := range args {
+		if _, ok := arg.(sql.NamedArg); ok {
+			return nil
+		}
+	}
+
+	want, ok := countPlaceholders(query, style, escapes)
+	if ok && want != len(args) {
+		return &ArgCountError{Name: name, Want: want, Got: len(args)}
+	}
+	return nil
+}
+
+// NumInput returns the number of arguments the query expects, or -1 if it
+// cannot be known in advance: the query does not exist, uses named
+// parameters or :1 placeholders, or fails to render with the data of d. As
+// with driver.Stmt.NumInput, a slice expanded into several placeholders
+// counts as a single argument.
+func (d DotSql) NumInput(name string) int {
+	query, err := d.renderQuery(name, d.data)
+	if err != nil {
+		return -1
+	}
+
+	q := d.descriptors[name]
+	n, ok := countPlaceholders(query, q.bind, q.escapes)
+	if !ok {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,118 @@
+package dotsql
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestCountPlaceholders(t *testing.T) {
+	var tests = []struct {
+		query string
+		style BindStyle
+		want  int
+		ok    bool
+	}{
+		{"SELECT 1", BindQuestion, 0, true},
+		{"INSERT INTO users (name, email) VALUES(?, ?)", BindQuestion, 2, true},
+		{"SELECT * FROM users WHERE note <> '?' AND data ?? 'k' AND id = ? -- ?", BindDollar, 1, true},
+		{"SELECT * FROM users WHERE note <> '?' AND data ?? 'k' AND id = ? -- ?", BindQuestion, 3, true},
+		{"SELECT * FROM users WHERE id = $1 OR parent = $1 OR name = $2", BindQuestion, 2, true},
+		{"SELECT * FROM t WHERE data ? 'k' AND id = $1", BindQuestion, 1, true},
+		{"SELECT * FROM t WHERE data ?| array['a', 'b'] AND data ?& $2 AND id = $1", BindQuestion, 2, true},
+		{"SELECT * FROM users WHERE a = ?1 OR b = ?1", BindQuestion, 1, true},
+		{"SELECT * FROM users WHERE a = ?2 OR b = ?1", BindQuestion, 2, true},
+		{"SELECT $$?$$, x::text, a$1, t.a:1 FROM t", BindQuestion, 0, true},
+		{"SELECT * FROM users WHERE name = :name", BindQuestion, 0, false},
+		{"SELECT * FROM users WHERE name = @name", BindQuestion, 0, false},
+		{"SELECT * FROM users WHERE name = $name", BindQuestion, 0, false},
+		{"SELECT * FROM users WHERE id = :1", BindQuestion, 0, false},
+		{"SELECT @@version", BindQuestion, 0, true},
+	}
+
+	for _, c := range tests {
+		got, ok := countPlaceholders(c.query, c.style, false)
+		if got != c.want || ok != c.ok {
+			t.Errorf("countPlaceholders(%q, %d) == (%d, %v), expected (%d, %v)", c.query, c.style, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestArgCountValidation(t *testing.T) {
+	dot, err := LoadFromString(`
+-- name: create-user
+INSERT INTO users (name, email) VALUES(?, ?)
+
+-- name: find-users
+SELECT * FROM users WHERE name = :name
+
+-- name: find-user
+SELECT * FROM users WHERE id = :1
+
+-- name: find-by-key
+SELECT * FROM users WHERE data ? 'k' AND id = $1
+`)
+	failIfError(t, err)
+
+	execer := &ExecerMock{
+		ExecFunc: func(_ string, _ ...interface{}) (sql.Result, error) {
+			return sqlResult{}, nil
+		},
+	}
+
+	_, err = dot.Exec(execer, "create-user", "foo")
+	var argErr *ArgCountError
+	if !errors.As(err, &argErr) {
+		t.Fatalf("expected *ArgCountError, got '%v'", err)
+	}
+	expectedErr := `dotsql: "create-user" expects 2 args, got 1`
+	if err.Error() != expectedErr {
+		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+	}
+	if len(execer.ExecCalls()) > 0 {
+		t.Error("exec was not expected to be called")
+	}
+
+	_, err = dot.Exec(execer, "create-user", "foo", "foo@bar.com")
+	failIfError(t, err)
+
+	_, err = dot.Exec(execer, "find-users", sql.Named("name", "foo"))
+	failIfError(t, err)
+
+	_, err = dot.Exec(execer, "find-user", 1)
+	failIfError(t, err)
+
+	_, err = dot.Exec(execer, "find-by-key", 1)
+	failIfError(t, err)
+}
+
+func TestNumInput(t *testing.T) {
+	dot, err := LoadFromString(`
+-- name: create-user
+INSERT INTO users (name, email) VALUES(?, ?)
+
+-- name: find-users
+SELECT * FROM users WHERE name = :name
+
+-- name: find-users-by-ids
+-- expand: true
+SELECT * FROM users WHERE id IN (?)
+
+-- name: broken-template
+SELECT * FROM users WHERE id = {{index .ids 1}}
+`)
+	failIfError(t, err)
+
+	tests := map[string]int{
+		"create-user":       2,
+		"find-users":        -1,
+		"find-users-by-ids": 1,
+		"broken-template":   -1,
+		"non-existent":      -1,
+	}
+	for name, want := range tests {
+		if got := dot.NumInput(name); got != want {
+			t.Errorf("NumInput(%q) == %d, expected %d", name, got, want)
+		}
+	}
+}
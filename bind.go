@@ -0,0 +1,75 @@
+package dotsql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// BindStyle is the placeholder syntax expected by a database driver.
+// Queries are written with ? placeholders, which are rewritten to the
+// selected style when run.
+type BindStyle int
+
+const (
+	BindQuestion BindStyle = iota // ?, as used by MySQL and SQLite; queries are not rewritten
+	BindDollar                    // $1, as used by PostgreSQL
+	BindAt                        // @p1, as used by SQL Server
+	BindColon                     // :1, as used by Oracle
+)
+
+// rebind rewrites the ? placeholders of query to style, leaving string
+// literals and comments untouched. A doubled ?? stands for a literal ?, e.g.
+// a PostgreSQL JSON operator. With BindQuestion the query is left as written,
+// ?? included.
+func rebind(query string, style BindStyle, escapes bool) string {
+	if style == BindQuestion || !strings.Contains(query, "?") {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, seg := range splitSQL(query, escapes) {
+		if !seg.code {
+			b.WriteString(seg.text)
+			continue
+		}
+
+		text := seg.text
+		for i := 0; i < len(text); i++ {
+			if text[i] != '?' {
+				b.WriteByte(text[i])
+				continue
+			}
+			if i+1 < len(text) && text[i+1] == '?' {
+				b.WriteByte('?')
+				i++
+				continue
+			}
+
+			n++
+			switch style {
+			case BindDollar:
+				b.WriteByte('$')
+			case BindAt:
+				b.WriteString("@p")
+			case BindColon:
+				b.WriteByte(':')
+			}
+			b.WriteString(strconv.Itoa(n))
+		}
+	}
+
+	return b.String()
+}
+
+// WithBindStyle returns a copy of d rewriting the ? placeholders of all its
+// queries to style.
+func (d DotSql) WithBindStyle(style BindStyle) DotSql {
+	descriptors := make(map[string]Query, len(d.descriptors))
+	for name, q := range d.descriptors {
+		q.bind = style
+		descriptors[name] = q
+	}
+	d.descriptors = descriptors
+	return d
+}
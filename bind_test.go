@@ -0,0 +1,47 @@
+package dotsql
+
+import "testing"
+
+func TestRebind(t *testing.T) {
+	query := "SELECT * FROM users WHERE name = ? AND note <> '?' /* ? */ AND data ?? 'key' AND email = ? -- ?"
+
+	var tests = []struct {
+		style BindStyle
+		want  string
+	}{
+		{BindQuestion, query},
+		{BindDollar, "SELECT * FROM users WHERE name = $1 AND note <> '?' /* ? */ AND data ? 'key' AND email = $2 -- ?"},
+		{BindAt, "SELECT * FROM users WHERE name = @p1 AND note <> '?' /* ? */ AND data ? 'key' AND email = @p2 -- ?"},
+		{BindColon, "SELECT * FROM users WHERE name = :1 AND note <> '?' /* ? */ AND data ? 'key' AND email = :2 -- ?"},
+	}
+
+	for _, c := range tests {
+		if got := rebind(query, c.style, false); got != c.want {
+			t.Errorf("rebind(%q, %d) == %q, expected %q", query, c.style, got, c.want)
+		}
+	}
+}
+
+func TestBindStyle(t *testing.T) {
+	sqlFile := "-- name: find-user\nSELECT * FROM users WHERE name = ? AND email = ?\n" +
+		"-- name: find-user-named\nSELECT * FROM users WHERE name = :name"
+
+	dot, err := LoadFromString(sqlFile, WithBindStyle(BindDollar))
+	failIfError(t, err)
+
+	expectedQuery := "SELECT * FROM users WHERE name = $1 AND email = $2"
+	if got := extractTemplate(t, *dot, "find-user"); got != expectedQuery {
+		t.Errorf("Raw() == %q, expected %q", got, expectedQuery)
+	}
+
+	expectedQuery = "SELECT * FROM users WHERE name = @p1 AND email = @p2"
+	if got := extractTemplate(t, dot.WithBindStyle(BindAt), "find-user"); got != expectedQuery {
+		t.Errorf("Raw() == %q, expected %q", got, expectedQuery)
+	}
+
+	query, _, err := dot.lookupNamed("find-user-named", map[string]any{"name": "foo"})
+	failIfError(t, err)
+	if expectedQuery := "SELECT * FROM users WHERE name = $1"; query != expectedQuery {
+		t.Errorf("lookupNamed() == %q, expected %q", query, expectedQuery)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"text/template"
+
+	"github.com/qustavo/dotsql"
+)
+
+// runLint reports the likely mistakes of the given query files: the warnings
+// of the dotsql Scanner, the errors that would make loading them fail, and
+// the queries that are not valid templates.
+func runLint(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	var load loadFlags
+	load.register(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: dotsql lint [flags] file.sql...\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	tags, err := load.tags()
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	policy, err := load.policy()
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+
+	var problems []problem
+	for _, file := range flags.Args() {
+		scanner := &dotsql.Scanner{
+			File:             file,
+			Tags:             tags,
+			Duplicates:       policy,
+			BackslashEscapes: load.escapes,
+		}
+		found, err := lint(scanner)
+		if err != nil {
+			log.Print(err)
+			return 2
+		}
+		problems = append(problems, found...)
+	}
+
+	sortProblems(problems)
+	for _, p := range problems {
+		fmt.Fprintln(stdout, p.msg)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// lint returns the problems of the file of scanner, read with its settings.
+func lint(scanner *dotsql.Scanner) ([]problem, error) {
+	f, err := os.Open(scanner.File)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var problems []problem
+	report := func(pos dotsql.Position, msg string) {
+		problems = append(problems, problem{
+			file: pos.File,
+			line: pos.StartLine,
+			msg:  fmt.Sprintf("%s:%d: %s", pos.File, pos.StartLine, msg),
+		})
+	}
+
+	lines := bufio.NewScanner(f)
+	lines.Buffer(nil, math.MaxInt)
+	lines.Split(dotsql.ScanLines)
+
+	scanner.Warn = report
+	queries := scanner.Run(lines)
+	// The other errors of the Scanner are reported as warnings too.
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+
+	positions := scanner.Positions()
+	for name, body := range queries {
+		if _, err := template.New(name).Parse(body); err != nil {
+			report(positions[name], fmt.Sprintf("query %q is not a valid template: %v", name, err))
+		}
+	}
+	return problems, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	file := filepath.Join("testdata", "lint.sql")
+	var stdout bytes.Buffer
+	status := runLint([]string{file}, &stdout)
+	if status != 1 {
+		t.Errorf("runLint() returned status %d, expected 1", status)
+	}
+
+	expected := []string{
+		file + `:1: text before the first name tag is ignored`,
+		file + `:3: unexpected "one" after the name tag of "find-users"`,
+		file + `:6: query "empty" has an empty body`,
+		file + `:8: query "find-users" interpolates template data where a value is expected, use a placeholder instead`,
+		file + `:8: query "find-users" already defined at ` + file + `:3-4`,
+		file + `:11: query "broken-template" is not a valid template: template: broken-template:1: unexpected EOF`,
+		file + `:14: query "delete-user" has an unknown command ":delete"`,
+		file + `:17: query "unterminated" has an unterminated string literal`,
+	}
+	if got := strings.Split(strings.TrimSpace(stdout.String()), "\n"); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("runLint() reported\n%s\nexpected\n%s", stdout.String(), strings.Join(expected, "\n"))
+	}
+}
+
+func TestLintClean(t *testing.T) {
+	var stdout bytes.Buffer
+	status := runLint([]string{filepath.Join("testdata", "app", "queries.sql")}, &stdout)
+	if status != 0 || stdout.Len() > 0 {
+		t.Errorf("runLint() returned status %d and reported:\n%s", status, stdout.String())
+	}
+}
+
+func TestLintOptions(t *testing.T) {
+	file := filepath.Join("testdata", "hugsql.sql")
+	var stdout bytes.Buffer
+	if status := runLint([]string{file}, &stdout); status != 1 {
+		t.Errorf("runLint() returned status %d, expected 1", status)
+	}
+
+	stdout.Reset()
+	status := runLint([]string{"-dialect", "hugsql", "-backslash-escapes", file}, &stdout)
+	if status != 0 || stdout.Len() > 0 {
+		t.Errorf("runLint() with -dialect hugsql returned status %d and reported\n%s", status, stdout.String())
+	}
+}
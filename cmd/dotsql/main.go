@@ -0,0 +1,145 @@
+// Command dotsql inspects dotsql query files and the Go code using them.
+//
+// Usage:
+//
+//	dotsql <command> [flags] file.sql...
+//
+// The commands are:
+//
+//	lint     report likely mistakes in query files
+//	unused   report queries never used by Go packages, and uses of unknown queries
+//
+// Both commands read query files as the dotsql loading functions do, with
+// flags matching their options: -dialect selects the name tag syntax,
+// -backslash-escapes reads strings and comments as MySQL does, and
+// -duplicates selects which definition of a query defined twice is kept.
+//
+// A command exits with status 1 if it reports any problem, making it usable
+// in CI.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/qustavo/dotsql"
+)
+
+// commands maps command names to their functions, which take the command
+// line arguments, write their report to stdout and return the exit status.
+var commands = map[string]func(args []string, stdout io.Writer) int{
+	"lint":   runLint,
+	"unused": runUnused,
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dotsql: ")
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "dotsql: unknown command %q\n", os.Args[1])
+		usage()
+	}
+	os.Exit(command(os.Args[2:], os.Stdout))
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "usage: dotsql <command> [flags] file.sql...\n\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %s\n", name)
+	}
+	os.Exit(2)
+}
+
+// loadFlags are the flags selecting how query files are read.
+type loadFlags struct {
+	dialect    string
+	escapes    bool
+	duplicates string
+}
+
+func (f *loadFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&f.dialect, "dialect", "default", "name tag `syntax` of the query files: default, yesql or hugsql")
+	flags.BoolVar(&f.escapes, "backslash-escapes", false, "read backslash escapes in strings and # comments, as MySQL does")
+	flags.StringVar(&f.duplicates, "duplicates", "last", "`policy` for queries defined twice: last, first or reject")
+}
+
+// tags returns the TagParser selected by -dialect.
+func (f *loadFlags) tags() (dotsql.TagParser, error) {
+	switch f.dialect {
+	case "default":
+		return dotsql.DefaultTags, nil
+	case "yesql":
+		return dotsql.YesqlTags, nil
+	case "hugsql":
+		return dotsql.HugSQLTags, nil
+	}
+	return nil, fmt.Errorf("unknown dialect %q", f.dialect)
+}
+
+// policy returns the DuplicatePolicy selected by -duplicates.
+func (f *loadFlags) policy() (dotsql.DuplicatePolicy, error) {
+	switch f.duplicates {
+	case "last":
+		return dotsql.DuplicateLastWins, nil
+	case "first":
+		return dotsql.DuplicateFirstWins, nil
+	case "reject":
+		return dotsql.DuplicateReject, nil
+	}
+	return 0, fmt.Errorf("unknown duplicate policy %q", f.duplicates)
+}
+
+// options returns the loading options selected by the flags.
+func (f *loadFlags) options() ([]dotsql.Option, error) {
+	tags, err := f.tags()
+	if err != nil {
+		return nil, err
+	}
+	policy, err := f.policy()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []dotsql.Option{dotsql.WithTagParser(tags), dotsql.WithDuplicates(policy)}
+	if f.escapes {
+		opts = append(opts, dotsql.BackslashEscapes())
+	}
+	return opts, nil
+}
+
+// problem is a problem reported by a command.
+type problem struct {
+	file string
+	line int
+	col  int
+	msg  string
+}
+
+// sortProblems sorts problems by position.
+func sortProblems(problems []problem) {
+	sort.SliceStable(problems, func(i, j int) bool {
+		a, b := problems[i], problems[j]
+		if a.file != b.file {
+			return a.file < b.file
+		}
+		if a.line != b.line {
+			return a.line < b.line
+		}
+		return a.col < b.col
+	})
+}
@@ -0,0 +1,18 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/qustavo/dotsql"
+)
+
+const findUsers = "find-users"
+
+func run(ctx context.Context, dot *dotsql.DotSql, db *sql.DB) {
+	dot.Exec(db, "create-user", "foo", "foo@bar.com")
+	dot.Query(db, findUsers)
+	dot.Query(db, "find-user")
+	dotsql.Scalar[int](ctx, dot, db, "count-users")
+	db.Exec("delete-users")
+}
@@ -0,0 +1,14 @@
+package app
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/qustavo/dotsql"
+)
+
+func TestCreateUser(t *testing.T) {
+	var dot *dotsql.DotSql
+	var db *sql.DB
+	dot.Exec(db, "create-users", "foo", "foo@bar.com")
+}
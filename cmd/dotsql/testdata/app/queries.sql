@@ -0,0 +1,11 @@
+-- name: create-user
+INSERT INTO users (name, email) VALUES (?, ?)
+
+-- name: find-users
+SELECT * FROM users
+
+-- name: count-users
+SELECT count(*) FROM users
+
+-- name: delete-users
+DELETE FROM users
@@ -0,0 +1,13 @@
+package dirapp
+
+import (
+	"database/sql"
+
+	"github.com/qustavo/dotsql"
+)
+
+func run(dot *dotsql.DotSql, db *sql.DB) {
+	dot.Query(db, "users.find-by-email", "foo@bar.com")
+	dot.Query(db, "billing.find-by-email", "foo@bar.com")
+	dot.Exec(db, "users.delete")
+}
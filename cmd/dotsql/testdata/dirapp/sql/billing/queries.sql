@@ -0,0 +1,2 @@
+-- name: find-by-email
+SELECT * FROM invoices WHERE email = ?
@@ -0,0 +1,5 @@
+-- name: find-by-email
+SELECT * FROM users WHERE email = ?
+
+-- name: create
+INSERT INTO users (email) VALUES (?)
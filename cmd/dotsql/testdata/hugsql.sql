@@ -0,0 +1,5 @@
+-- :name save-user :! :n
+INSERT INTO users (name) VALUES ('it\'s')
+
+-- :name find-users :? :*
+SELECT * FROM users # all of them
@@ -0,0 +1,18 @@
+SELECT 1;
+
+-- name: find-users one
+SELECT * FROM users
+
+-- name: empty
+
+-- name: find-users
+SELECT * FROM users WHERE id = {{.id}}
+
+-- name: broken-template
+SELECT * FROM users {{if .active}}WHERE active
+
+-- name: delete-user :delete
+DELETE FROM users WHERE id = ?
+
+-- name: unterminated
+SELECT * FROM users WHERE name = 'foo
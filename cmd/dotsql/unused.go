@@ -0,0 +1,178 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/qustavo/dotsql"
+	"github.com/qustavo/dotsql/internal/calls"
+	"golang.org/x/tools/go/packages"
+)
+
+// runUnused reports the queries of the given files that no Go package uses,
+// and the uses of queries not defined in those files. Queries are used by
+// passing their name as a constant to a DotSql method or a generic helper
+// such as Select; names computed at run time are not seen. With -root, the
+// queries of every .sql file below the directory are namespaced as
+// dotsql.LoadFromDir does.
+func runUnused(args []string, stdout io.Writer) int {
+	flags := flag.NewFlagSet("unused", flag.ExitOnError)
+	dir := flags.String("C", ".", "load the Go packages from `dir`")
+	patterns := flags.String("pkg", "./...", "space separated `patterns` of the Go packages using the queries")
+	root := flags.String("root", "", "load the queries of `dir` as dotsql.LoadFromDir does")
+	separator := flags.String("separator", ".", "`separator` of the namespaces of the queries loaded with -root")
+	var load loadFlags
+	load.register(flags)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: dotsql unused [flags] [-root dir] [file.sql...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 && *root == "" {
+		flags.Usage()
+		return 2
+	}
+
+	opts, err := load.options()
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	queries, err := loadPositions(flags.Args(), opts)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	if *root != "" {
+		err = loadDirPositions(queries, *root, append(opts, dotsql.WithSeparator(*separator)))
+	}
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	refs, err := findReferences(*dir, strings.Fields(*patterns))
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+
+	problems := unused(queries, refs)
+	sortProblems(problems)
+	for _, p := range problems {
+		fmt.Fprintln(stdout, p.msg)
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// loadPositions returns the position of every query of files, loaded with
+// opts.
+func loadPositions(files []string, opts []dotsql.Option) (map[string]dotsql.Position, error) {
+	queries := make(map[string]dotsql.Position)
+	for _, file := range files {
+		dot, err := dotsql.LoadFromFile(file, opts...)
+		if err != nil {
+			return nil, err
+		}
+		for name := range dot.QueryMap() {
+			queries[name], _ = dot.Position(name)
+		}
+	}
+	return queries, nil
+}
+
+// loadDirPositions adds to queries the position of every query loaded from
+// root by dotsql.LoadFromDir with opts.
+func loadDirPositions(queries map[string]dotsql.Position, root string, opts []dotsql.Option) error {
+	dot, err := dotsql.LoadFromDir(os.DirFS(root), ".", opts...)
+	if err != nil {
+		return err
+	}
+	for name := range dot.QueryMap() {
+		pos, _ := dot.Position(name)
+		pos.File = filepath.Join(root, filepath.FromSlash(pos.File))
+		queries[name] = pos
+	}
+	return nil
+}
+
+// findReferences returns the position of every use of a query by the
+// packages matching patterns, by name.
+func findReferences(dir string, patterns []string) (map[string][]token.Position, error) {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:   dir,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, fmt.Errorf("could not load Go packages")
+	}
+
+	refs := make(map[string][]token.Position)
+	seen := make(map[token.Position]bool)
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				expr, ok := n.(*ast.CallExpr)
+				if !ok {
+					return true
+				}
+				name, c, ok := calls.QueryName(pkg.TypesInfo, expr)
+				if !ok {
+					return true
+				}
+
+				// Packages with tests are loaded more than once.
+				pos := pkg.Fset.Position(expr.Args[c.Name].Pos())
+				if !seen[pos] {
+					seen[pos] = true
+					refs[name] = append(refs[name], pos)
+				}
+				return true
+			})
+		}
+	}
+	return refs, nil
+}
+
+// unused returns the queries without references, and the references to
+// unknown queries.
+func unused(queries map[string]dotsql.Position, refs map[string][]token.Position) []problem {
+	var problems []problem
+	for name, pos := range queries {
+		if len(refs[name]) == 0 {
+			problems = append(problems, problem{
+				file: pos.File,
+				line: pos.StartLine,
+				msg:  fmt.Sprintf("%s:%d: query %q is never used", pos.File, pos.StartLine, name),
+			})
+		}
+	}
+	for name, positions := range refs {
+		if _, ok := queries[name]; ok {
+			continue
+		}
+		for _, pos := range positions {
+			problems = append(problems, problem{
+				file: pos.Filename,
+				line: pos.Line,
+				col:  pos.Column,
+				msg:  fmt.Sprintf("%s: unknown query %q", pos, name),
+			})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnused(t *testing.T) {
+	file := filepath.Join("testdata", "app", "queries.sql")
+	var stdout bytes.Buffer
+	status := runUnused([]string{"-C", filepath.Join("testdata", "app"), "-pkg", ".", file}, &stdout)
+	if status != 1 {
+		t.Errorf("runUnused() returned status %d, expected 1", status)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	expected := []string{
+		`app.go:15:16: unknown query "find-user"`,
+		`app_test.go:13:15: unknown query "create-users"`,
+		`queries.sql:10: query "delete-users" is never used`,
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("runUnused() reported:\n%s\nexpected %d problems", stdout.String(), len(expected))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, expected[i]) {
+			t.Errorf("problem %d is %q, expected it to end with %q", i, line, expected[i])
+		}
+	}
+}
+
+func TestUnusedRoot(t *testing.T) {
+	dir := filepath.Join("testdata", "dirapp")
+	var stdout bytes.Buffer
+	status := runUnused([]string{"-C", dir, "-pkg", ".", "-root", filepath.Join(dir, "sql")}, &stdout)
+	if status != 1 {
+		t.Errorf("runUnused() returned status %d, expected 1", status)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	expected := []string{
+		`app.go:12:15: unknown query "users.delete"`,
+		filepath.Join(dir, "sql", "users", "queries.sql") + `:4: query "users.create" is never used`,
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("runUnused() reported:\n%s\nexpected %d problems", stdout.String(), len(expected))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, expected[i]) {
+			t.Errorf("problem %d is %q, expected it to end with %q", i, line, expected[i])
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+
+	"github.com/qustavo/dotsql"
+)
+
+// generateConsts returns the formatted source of a file of package pkg
+// declaring a constant holding the name of every query. Constant names are
+// the Go identifiers derived from the query names, with prefix prepended.
+func generateConsts(pkg, prefix string, queries []dotsql.Query) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by dotsqlgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "// Query names.\n")
+	fmt.Fprintf(&buf, "const (\n")
+
+	names := make(map[string]string)
+	for _, query := range queries {
+		name, err := goName(query.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%q (%s): %w", query.Name, query.Position, err)
+		}
+		name = prefix + name
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("%q and %q both generate constant %s", other, query.Name, name)
+		}
+		names[name] = query.Name
+
+		fmt.Fprintf(&buf, "%s = %q\n", name, query.Name)
+	}
+	fmt.Fprintf(&buf, ")\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateConsts(t *testing.T) {
+	queries := loadTestQueries(t, "-- name: find-user-by-id\nSELECT 1\n\n-- name: create_user\nSELECT 2")
+
+	src, err := generateConsts("db", "", queries)
+	if err != nil {
+		t.Fatalf("generateConsts() returned error: %s", err)
+	}
+	expected := `// Code generated by dotsqlgen. DO NOT EDIT.
+
+package db
+
+// Query names.
+const (
+	FindUserByID = "find-user-by-id"
+	CreateUser   = "create_user"
+)
+`
+	if string(src) != expected {
+		t.Errorf("generateConsts() ==\n%s\nexpected\n%s", src, expected)
+	}
+
+	src, err = generateConsts("db", "Query", queries)
+	if err != nil {
+		t.Fatalf("generateConsts() returned error: %s", err)
+	}
+	if !strings.Contains(string(src), `QueryFindUserByID = "find-user-by-id"`) {
+		t.Errorf("generateConsts() did not apply the prefix:\n%s", src)
+	}
+
+	queries = loadTestQueries(t, "-- name: get-user\nSELECT 1\n-- name: get_user\nSELECT 2")
+	_, err = generateConsts("db", "", queries)
+	expectedErr := `"get-user" and "get_user" both generate constant GetUser`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("generateConsts() returned error %v, expected %q", err, expectedErr)
+	}
+}
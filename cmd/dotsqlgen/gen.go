@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"sort"
+	"strings"
+
+	"github.com/qustavo/dotsql"
+)
+
+// packages maps the package names allowed in annotation types to their
+// import paths.
+var packages = map[string]string{
+	"driver": "database/sql/driver",
+	"json":   "encoding/json",
+	"sql":    "database/sql",
+	"time":   "time",
+}
+
+// field is a parameter or result column declared by an annotation.
+type field struct {
+	Column string // name as written in the annotation
+	Name   string // Go name
+	Type   string
+}
+
+// method is the Go method generated for a query.
+type method struct {
+	Name    string
+	Query   dotsql.Query
+	Command dotsql.Command
+	Params  []field
+	Result  []field
+}
+
+// generator writes a Go file with a method per query.
+type generator struct {
+	pkg     string
+	typ     string
+	imports map[string]bool
+	buf     bytes.Buffer
+}
+
+// generate returns the formatted source of a file of package pkg declaring
+// the type typ, with a method per query.
+func generate(pkg, typ string, queries []dotsql.Query) ([]byte, error) {
+	g := &generator{pkg: pkg, typ: typ, imports: map[string]bool{"context": true}}
+
+	var methods []method
+	names := make(map[string]string)
+	for _, query := range queries {
+		m, err := g.method(query)
+		if err != nil {
+			return nil, fmt.Errorf("%q (%s): %w", query.Name, query.Position, err)
+		}
+		if other, ok := names[m.Name]; ok {
+			return nil, fmt.Errorf("%q and %q both generate method %s", other, query.Name, m.Name)
+		}
+		names[m.Name] = query.Name
+		methods = append(methods, m)
+	}
+
+	g.header()
+	for _, m := range methods {
+		g.structs(m)
+		g.body(m)
+	}
+
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// method derives the method generated for query from its annotations.
+func (g *generator) method(query dotsql.Query) (method, error) {
+	name, err := goName(query.Name)
+	if err != nil {
+		return method{}, err
+	}
+
+	params, err := g.fields(query.Metadata["params"])
+	if err != nil {
+		return method{}, fmt.Errorf("params: %w", err)
+	}
+	result, err := g.fields(query.Metadata["result"])
+	if err != nil {
+		return method{}, fmt.Errorf("result: %w", err)
+	}
+
+	command := query.Command
+	if command == dotsql.CommandNone {
+		command = dotsql.CommandExecResult
+		if len(result) > 0 {
+			command = dotsql.CommandMany
+		}
+	}
+
+	switch command {
+	case dotsql.CommandOne, dotsql.CommandMany:
+		if len(result) == 0 {
+			return method{}, fmt.Errorf("%s query has no result annotation", command)
+		}
+	default:
+		if len(result) > 0 {
+			return method{}, fmt.Errorf("%s query has a result annotation", command)
+		}
+	}
+	if command == dotsql.CommandExecResult {
+		g.imports["database/sql"] = true
+	}
+
+	return method{
+		Name:    name,
+		Query:   query,
+		Command: command,
+		Params:  params,
+		Result:  result,
+	}, nil
+}
+
+// fields parses a list of fields such as "id int64, tags []string".
+func (g *generator) fields(spec string) ([]field, error) {
+	var fields []field
+	names := make(map[string]bool)
+	for _, decl := range splitFields(spec) {
+		column, typ, ok := strings.Cut(strings.TrimSpace(decl), " ")
+		typ = strings.TrimSpace(typ)
+		if !ok || typ == "" {
+			return nil, fmt.Errorf("missing type in %q", decl)
+		}
+
+		name, err := goName(column)
+		if err != nil {
+			return nil, err
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicate field %s", name)
+		}
+		names[name] = true
+
+		if err := g.checkType(typ); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field{Column: column, Name: name, Type: typ})
+	}
+	return fields, nil
+}
+
+// checkType reports whether typ is a valid type expression, and records the
+// packages it refers to.
+func (g *generator) checkType(typ string) error {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return fmt.Errorf("invalid type %q", typ)
+	}
+
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return err == nil
+		}
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok {
+			err = fmt.Errorf("invalid type %q", typ)
+			return false
+		}
+		path, ok := packages[pkg.Name]
+		if !ok {
+			err = fmt.Errorf("unknown package %q in type %q", pkg.Name, typ)
+			return false
+		}
+		g.imports[path] = true
+		return false
+	})
+	return err
+}
+
+// splitFields splits spec on the commas that are not nested in brackets,
+// braces or parentheses.
+func splitFields(spec string) []string {
+	if strings.TrimSpace(spec) == "" {
+		return nil
+	}
+
+	var fields []string
+	depth, start := 0, 0
+	for i, c := range spec {
+		switch c {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				fields = append(fields, spec[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(fields, spec[start:])
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) header() {
+	g.printf("// Code generated by dotsqlgen. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", g.pkg)
+
+	imports := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+
+	g.printf("import (\n")
+	for _, path := range imports {
+		g.printf("%q\n", path)
+	}
+	g.printf("\n%q\n)\n\n", "github.com/qustavo/dotsql")
+
+	g.printf("// %sDB is the database, or transaction, %[1]s runs queries on.\n", g.typ)
+	g.printf("type %sDB interface {\n", g.typ)
+	g.printf("dotsql.QueryerContext\n")
+	g.printf("dotsql.ExecerContext\n")
+	g.printf("}\n\n")
+
+	g.printf("// %s runs queries loaded into a DotSql through typed methods.\n", g.typ)
+	g.printf("type %s struct {\n", g.typ)
+	g.printf("dot *dotsql.DotSql\n")
+	g.printf("db %sDB\n", g.typ)
+	g.printf("}\n\n")
+
+	g.printf("// New%s returns a %[1]s running the queries of dot on db.\n", g.typ)
+	g.printf("func New%s(dot *dotsql.DotSql, db %[1]sDB) *%[1]s {\n", g.typ)
+	g.printf("return &%s{dot: dot, db: db}\n", g.typ)
+	g.printf("}\n")
+}
+
+// structs writes the parameter and result structs of m, if any.
+func (g *generator) structs(m method) {
+	if len(m.Params) > 0 {
+		g.printf("\n// %sParams holds the arguments of the %q query.\n", m.Name, m.Query.Name)
+		g.printf("type %sParams struct {\n", m.Name)
+		for _, f := range m.Params {
+			g.printf("%s %s\n", f.Name, f.Type)
+		}
+		g.printf("}\n")
+	}
+
+	if len(m.Result) > 1 {
+		g.printf("\n// %sRow is a row returned by the %q query.\n", m.Name, m.Query.Name)
+		g.printf("type %sRow struct {\n", m.Name)
+		for _, f := range m.Result {
+			g.printf("%s %s `db:%q`\n", f.Name, f.Type, f.Column)
+		}
+		g.printf("}\n")
+	}
+}
+
+// body writes the method running m.
+func (g *generator) body(m method) {
+	g.printf("\n// %s runs the %q query.\n", m.Name, m.Query.Name)
+	if m.Query.Description != "" {
+		g.printf("//\n")
+		for _, line := range strings.Split(m.Query.Description, "\n") {
+			g.printf("// %s\n", line)
+		}
+	}
+
+	params := "ctx context.Context"
+	args := fmt.Sprintf("%q", m.Query.Name)
+	if len(m.Params) > 0 {
+		params += fmt.Sprintf(", arg %sParams", m.Name)
+		for _, f := range m.Params {
+			args += ", arg." + f.Name
+		}
+	}
+
+	row := m.Name + "Row"
+	if len(m.Result) == 1 {
+		row = m.Result[0].Type
+	}
+
+	switch m.Command {
+	case dotsql.CommandOne:
+		g.printf("func (q *%s) %s(%s) (%s, error) {\n", g.typ, m.Name, params, row)
+		g.printf("return dotsql.Get[%s](ctx, q.dot, q.db, %s)\n", row, args)
+	case dotsql.CommandMany:
+		g.printf("func (q *%s) %s(%s) ([]%s, error) {\n", g.typ, m.Name, params, row)
+		g.printf("return dotsql.Select[%s](ctx, q.dot, q.db, %s)\n", row, args)
+	case dotsql.CommandExec:
+		g.printf("func (q *%s) %s(%s) error {\n", g.typ, m.Name, params)
+		g.printf("_, err := q.dot.ExecContext(ctx, q.db, %s)\n", args)
+		g.printf("return err\n")
+	case dotsql.CommandExecRows, dotsql.CommandExecLastID:
+		result := "RowsAffected"
+		if m.Command == dotsql.CommandExecLastID {
+			result = "LastInsertId"
+		}
+		g.printf("func (q *%s) %s(%s) (int64, error) {\n", g.typ, m.Name, params)
+		g.printf("res, err := q.dot.ExecContext(ctx, q.db, %s)\n", args)
+		g.printf("if err != nil {\nreturn 0, err\n}\n")
+		g.printf("return res.%s()\n", result)
+	default:
+		g.printf("func (q *%s) %s(%s) (sql.Result, error) {\n", g.typ, m.Name, params)
+		g.printf("return q.dot.ExecContext(ctx, q.db, %s)\n", args)
+	}
+	g.printf("}\n")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qustavo/dotsql"
+)
+
+const testQueries = `
+-- Finds the users with the given email.
+-- name: find-users-by-email :many
+-- params: email string
+-- result: id int64, name string, email sql.NullString
+SELECT id, name, email FROM users WHERE email = ?
+
+-- name: count-users :one
+-- result: count int64
+SELECT count(*) FROM users
+
+-- name: create-user :execlastid
+-- params: name string, email string
+INSERT INTO users (name, email) VALUES (?, ?)
+
+-- name: delete-users
+DELETE FROM users
+
+-- name: touch-users :exec
+-- params: at time.Time, ids []int64
+-- expand: true
+UPDATE users SET updated_at = ? WHERE id IN (?)
+`
+
+func loadTestQueries(t *testing.T, sql string) []dotsql.Query {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "queries.sql")
+	if err := os.WriteFile(file, []byte(sql), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	queries, err := loadQueries([]string{file})
+	if err != nil {
+		t.Fatalf("loadQueries() returned error: %s", err)
+	}
+	return queries
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := generate("db", "Queries", loadTestQueries(t, testQueries))
+	if err != nil {
+		t.Fatalf("generate() returned error: %s", err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "queries.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %s\n%s", err, src)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("db", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("generated code does not type check: %s\n%s", err, src)
+	}
+
+	signatures := map[string]string{
+		"FindUsersByEmail": "func(ctx context.Context, arg db.FindUsersByEmailParams) ([]db.FindUsersByEmailRow, error)",
+		"CountUsers":       "func(ctx context.Context) (int64, error)",
+		"CreateUser":       "func(ctx context.Context, arg db.CreateUserParams) (int64, error)",
+		"DeleteUsers":      "func(ctx context.Context) (database/sql.Result, error)",
+		"TouchUsers":       "func(ctx context.Context, arg db.TouchUsersParams) error",
+	}
+	queries := types.NewPointer(pkg.Scope().Lookup("Queries").Type())
+	for name, expected := range signatures {
+		obj, _, _ := types.LookupFieldOrMethod(queries, false, pkg, name)
+		if obj == nil {
+			t.Errorf("method %s not generated", name)
+			continue
+		}
+		if sig := obj.Type().String(); sig != expected {
+			t.Errorf("%s has signature %s, expected %s", name, sig, expected)
+		}
+	}
+
+	for _, snippet := range []string{
+		"// Code generated by dotsqlgen. DO NOT EDIT.",
+		"// Finds the users with the given email.",
+		"Email sql.NullString `db:\"email\"`",
+		`dotsql.Get[int64](ctx, q.dot, q.db, "count-users")`,
+		`q.dot.ExecContext(ctx, q.db, "touch-users", arg.At, arg.IDs)`,
+	} {
+		if !strings.Contains(string(src), snippet) {
+			t.Errorf("generated code does not contain %q:\n%s", snippet, src)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := map[string]string{
+		"-- name: find-users :many\nSELECT * FROM users":                                ":many query has no result annotation",
+		"-- name: delete-users :exec\n-- result: id int64\nDELETE FROM users":           ":exec query has a result annotation",
+		"-- name: find-user\n-- params: id\nSELECT * FROM users WHERE id = ?":           `missing type in "id"`,
+		"-- name: find-user\n-- params: id uuid.UUID\nSELECT * FROM users WHERE id = ?": `unknown package "uuid"`,
+		"-- name: find-user\n-- params: id map[int\nSELECT * FROM users WHERE id = ?":   `invalid type "map[int"`,
+		"-- name: find-user\n-- params: id int, ID int\nSELECT 1":                       "duplicate field ID",
+		"-- name: get-user\nSELECT 1\n-- name: get_user\nSELECT 2":                      `"get-user" and "get_user" both generate method GetUser`,
+	}
+	for sql, expected := range tests {
+		_, err := generate("db", "Queries", loadTestQueries(t, sql))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("generate() returned error %v, expected %q", err, expected)
+		}
+	}
+}
+
+func TestLoadQueriesDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.sql", "b.sql"} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte("-- name: find-users\nSELECT 1"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	_, err := loadQueries(files)
+	if _, ok := err.(*dotsql.DuplicateError); !ok {
+		t.Errorf("loadQueries() returned error %v, expected a *DuplicateError", err)
+	}
+}
@@ -0,0 +1,127 @@
+// Command dotsqlgen generates a Go file with a typed method for every query
+// of one or more dotsql files, checked by the compiler instead of looked up
+// by name at run time.
+//
+// Usage:
+//
+//	dotsqlgen [-pkg name] [-type Queries] [-o file] file.sql...
+//
+// Parameters and result columns are declared with annotations below the name
+// tag, and the sqlc command, if any, tells how the query is run:
+//
+//	-- name: find-users-by-email :many
+//	-- params: email string
+//	-- result: id int64, name string, email sql.NullString
+//	SELECT id, name, email FROM users WHERE email = ?
+//
+// generates
+//
+//	func (q *Queries) FindUsersByEmail(ctx context.Context, arg FindUsersByEmailParams) ([]FindUsersByEmailRow, error)
+//
+// The parameters are passed to the query in the order they are declared.
+// A query returning a single column is scanned into a value of its type
+// rather than a struct. Queries returning rows need a result annotation;
+// without a command, they are run as :many, and other queries return the
+// sql.Result. Types may refer to the sql, driver, json and time packages.
+//
+// With -consts, dotsqlgen instead generates a constant holding the name of
+// every query, as in
+//
+//	const (
+//		FindUsersByEmail = "find-users-by-email"
+//	)
+//
+// to be passed to DotSql methods in place of string literals. Two queries
+// whose names give the same identifier are an error.
+//
+// Running from go:generate, the package name defaults to $GOPACKAGE:
+//
+//	//go:generate dotsqlgen -o queries.go queries.sql
+//	//go:generate dotsqlgen -consts -o names.go queries.sql
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/qustavo/dotsql"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("dotsqlgen: ")
+
+	output := flag.String("o", "", "write the generated code to `file` instead of stdout")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package `name` of the generated code")
+	typ := flag.String("type", "Queries", "`name` of the generated type")
+	consts := flag.Bool("consts", false, "generate constants for the query names instead of methods")
+	prefix := flag.String("prefix", "", "`prefix` of the constant names generated with -consts")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: dotsqlgen [flags] file.sql...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	queries, err := loadQueries(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var src []byte
+	if *consts {
+		src, err = generateConsts(*pkg, *prefix, queries)
+	} else {
+		src, err = generate(*pkg, *typ, queries)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = os.WriteFile(*output, src, 0o644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// loadQueries returns the queries of files, sorted by position. A query
+// defined in more than one file is an error.
+func loadQueries(files []string) ([]dotsql.Query, error) {
+	var queries []dotsql.Query
+	seen := make(map[string]dotsql.Position)
+	for _, file := range files {
+		dot, err := dotsql.LoadFromFile(file, dotsql.Strict())
+		if err != nil {
+			return nil, err
+		}
+
+		for name := range dot.QueryMap() {
+			query, _ := dot.Lookup(name)
+			if pos, ok := seen[name]; ok {
+				return nil, &dotsql.DuplicateError{Name: name, First: pos, Second: query.Position}
+			}
+			seen[name] = query.Position
+			queries = append(queries, query)
+		}
+	}
+
+	sort.Slice(queries, func(i, j int) bool {
+		a, b := queries[i].Position, queries[j].Position
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.StartLine < b.StartLine
+	})
+	return queries, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// initialisms are written in upper case in Go identifiers, as golint wants.
+var initialisms = map[string]bool{
+	"api":  true,
+	"db":   true,
+	"html": true,
+	"http": true,
+	"id":   true,
+	"ip":   true,
+	"json": true,
+	"sql":  true,
+	"uid":  true,
+	"uri":  true,
+	"url":  true,
+	"uuid": true,
+	"xml":  true,
+}
+
+// goName converts a query or column name such as "find-user-by-id" or
+// "created_at" to an exported Go identifier such as FindUserByID or
+// CreatedAt. Names already in CamelCase keep their case.
+func goName(name string) (string, error) {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, word := range words {
+		lower := strings.ToLower(word)
+		if initialisms[lower] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		if plural, ok := strings.CutSuffix(lower, "s"); ok && initialisms[plural] {
+			b.WriteString(strings.ToUpper(plural) + "s")
+			continue
+		}
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+
+	ident := b.String()
+	if ident == "" || !unicode.IsLetter([]rune(ident)[0]) {
+		return "", fmt.Errorf("cannot derive a Go identifier from %q", name)
+	}
+	return ident, nil
+}
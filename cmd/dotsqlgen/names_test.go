@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"find-users-by-email": "FindUsersByEmail",
+		"find_user_by_id":     "FindUserByID",
+		"GetUser":             "GetUser",
+		"users.find-all":      "UsersFindAll",
+		"user_ids":            "UserIDs",
+		"url":                 "URL",
+		"v2-users":            "V2Users",
+	}
+	for name, expected := range tests {
+		ident, err := goName(name)
+		if err != nil {
+			t.Errorf("goName(%q) returned error: %s", name, err)
+			continue
+		}
+		if ident != expected {
+			t.Errorf("goName(%q) == %q, expected %q", name, ident, expected)
+		}
+	}
+
+	for _, name := range []string{"", "---", "2fa"} {
+		if _, err := goName(name); err == nil {
+			t.Errorf("goName(%q) did not return an error", name)
+		}
+	}
+}
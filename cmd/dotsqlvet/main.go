@@ -0,0 +1,20 @@
+// Command dotsqlvet runs the dotsqlcheck analyzer, reporting calls to dotsql
+// naming queries that do not exist or passing the wrong number of arguments.
+//
+// It can be run directly on packages:
+//
+//	dotsqlvet ./...
+//
+// or by go vet:
+//
+//	go vet -vettool=$(which dotsqlvet) ./...
+package main
+
+import (
+	"github.com/qustavo/dotsql/analysis/dotsqlcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(dotsqlcheck.Analyzer)
+}
@@ -0,0 +1,58 @@
+package dotsql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Command tells what a query returns, using the sqlc convention of a suffix
+// after the query name: "-- name: GetUser :one".
+type Command string
+
+const (
+	CommandNone       Command = ""            // no command declared
+	CommandOne        Command = ":one"        // returns a single row
+	CommandMany       Command = ":many"       // returns any number of rows
+	CommandExec       Command = ":exec"       // returns no rows
+	CommandExecRows   Command = ":execrows"   // returns no rows, callers want the number of affected rows
+	CommandExecResult Command = ":execresult" // returns no rows, callers want the sql.Result
+	CommandExecLastID Command = ":execlastid" // returns no rows, callers want the last insert id
+)
+
+// valid reports whether c is a known command.
+func (c Command) valid() bool {
+	switch c {
+	case CommandNone, CommandOne, CommandMany, CommandExec, CommandExecRows, CommandExecResult, CommandExecLastID:
+		return true
+	}
+	return false
+}
+
+// returnsRows reports whether queries declaring c return rows.
+func (c Command) returnsRows() bool {
+	return c == CommandOne || c == CommandMany
+}
+
+// checkCommand returns an error if the query declares a command that cannot
+// be run by method, e.g. an :exec query passed to Query. Queries without a
+// command can be run by any method.
+func (d DotSql) checkCommand(name, method string) error {
+	command := d.descriptors[name].Command
+	if command == CommandNone {
+		return nil
+	}
+
+	var ok bool
+	switch strings.TrimSuffix(strings.TrimSuffix(method, "Context"), "Named") {
+	case "Query":
+		ok = command.returnsRows()
+	case "QueryRow":
+		ok = command == CommandOne
+	case "Exec":
+		ok = !command.returnsRows()
+	}
+	if !ok {
+		return d.queryError(name, fmt.Errorf("%s query cannot be run with %s", command, method))
+	}
+	return nil
+}
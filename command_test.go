@@ -0,0 +1,85 @@
+package dotsql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestCommandEnforcement(t *testing.T) {
+	dot, err := LoadFromString(`
+-- name: GetUser :one
+SELECT * FROM users WHERE id = 1
+
+-- name: ListUsers :many
+SELECT * FROM users
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = 1
+
+-- name: untyped
+SELECT 1
+`)
+	failIfError(t, err)
+
+	query, _ := dot.Lookup("GetUser")
+	if query.Command != CommandOne {
+		t.Errorf("Lookup().Command == %q, expected %q", query.Command, CommandOne)
+	}
+
+	ctx := context.Background()
+	queryer := &QueryerContextMock{
+		QueryContextFunc: func(_ context.Context, _ string, _ ...interface{}) (*sql.Rows, error) {
+			return &sql.Rows{}, nil
+		},
+	}
+	rower := &QueryRowerMock{
+		QueryRowFunc: func(_ string, _ ...interface{}) *sql.Row {
+			return &sql.Row{}
+		},
+	}
+	execer := &ExecerMock{
+		ExecFunc: func(_ string, _ ...interface{}) (sql.Result, error) {
+			return sqlResult{}, nil
+		},
+	}
+
+	var tests = []struct {
+		name    string
+		run     func(name string) error
+		allowed map[string]bool
+	}{
+		{
+			"QueryContext",
+			func(name string) error { _, err := dot.QueryContext(ctx, queryer, name); return err },
+			map[string]bool{"GetUser": true, "ListUsers": true, "untyped": true},
+		},
+		{
+			"QueryRow",
+			func(name string) error { _, err := dot.QueryRow(rower, name); return err },
+			map[string]bool{"GetUser": true, "untyped": true},
+		},
+		{
+			"Exec",
+			func(name string) error { _, err := dot.Exec(execer, name); return err },
+			map[string]bool{"DeleteUser": true, "untyped": true},
+		},
+	}
+
+	for _, c := range tests {
+		for _, name := range []string{"GetUser", "ListUsers", "DeleteUser", "untyped"} {
+			err := c.run(name)
+			if c.allowed[name] && err != nil {
+				t.Errorf("%s(%q) returned unexpected error '%v'", c.name, name, err)
+			} else if !c.allowed[name] && err == nil {
+				t.Errorf("%s(%q) expected to fail", c.name, name)
+			}
+		}
+	}
+
+	_, err = dot.Query(&QueryerMock{}, "DeleteUser")
+	expectedErr := `dotsql: "DeleteUser" (line 8-9): :exec query cannot be run with Query`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"database/sql"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"text/template"
 )
@@ -57,17 +58,53 @@ type ExecerContext interface {
 	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 }
 
+// Query describes a loaded query.
+type Query struct {
+	Name     string
+	Position Position
+	// Command is the command declared after the query name, if any.
+	Command Command
+	// Description is the text of the comment lines immediately preceding
+	// the name tag, without the leading "--".
+	Description string
+	// Metadata holds the "-- key: value" annotations written right below
+	// the name tag, such as "-- timeout: 2s". Keys are lowercase, so that
+	// comments such as "-- TODO: fix" stay part of the query. It must not be
+	// modified.
+	Metadata map[string]string
+
+	// bind and escapes are the bind style and backslash escaping the query
+	// was loaded with, kept per query so that Merge does not change them.
+	bind    BindStyle
+	escapes bool
+}
+
 // DotSql represents a dotSQL queries holder.
 type DotSql struct {
-	queries map[string]*template.Template
-	data    any
+	queries     map[string]*template.Template
+	descriptors map[string]Query
+	data        any
 }
 
 func (d DotSql) WithData(data any) DotSql {
-	return DotSql{queries: d.queries, data: data}
+	d.data = data
+	return d
 }
 
+// lookupQuery returns the query rendered with data, its placeholders
+// rewritten to the bind style of the query.
 func (d DotSql) lookupQuery(name string, data any) (string, error) {
+	query, err := d.renderQuery(name, data)
+	if err != nil {
+		return "", err
+	}
+
+	q := d.descriptors[name]
+	return rebind(query, q.bind, q.escapes), nil
+}
+
+// renderQuery returns the query template executed with data.
+func (d DotSql) renderQuery(name string, data any) (string, error) {
 	template, ok := d.queries[name]
 	if !ok {
 		return "", fmt.Errorf("dotsql: '%s' could not be found", name)
@@ -78,12 +115,21 @@ func (d DotSql) lookupQuery(name string, data any) (string, error) {
 	buffer := bytes.NewBufferString("")
 	err := template.Execute(buffer, data)
 	if err != nil {
-		return "", fmt.Errorf("error parsing template: %w", err)
+		return "", d.queryError(name, fmt.Errorf("error parsing template: %w", err))
 	}
 
 	return buffer.String(), nil
 }
 
+// queryError annotates err with the name and, when known, the position of
+// the query.
+func (d DotSql) queryError(name string, err error) error {
+	if query, ok := d.descriptors[name]; ok {
+		return fmt.Errorf("dotsql: %q (%s): %w", name, query.Position, err)
+	}
+	return fmt.Errorf("dotsql: %q: %w", name, err)
+}
+
 // Prepare is a wrapper for database/sql's Prepare(), using dotsql named query.
 func (d DotSql) Prepare(db Preparer, name string) (*sql.Stmt, error) {
 	query, err := d.lookupQuery(name, d.data)
@@ -91,7 +137,12 @@ func (d DotSql) Prepare(db Preparer, name string) (*sql.Stmt, error) {
 		return nil, err
 	}
 
-	return db.Prepare(query)
+	stmt, err := db.Prepare(query)
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return stmt, nil
 }
 
 // PrepareContext is a wrapper for database/sql's PrepareContext(), using dotsql named query.
@@ -101,32 +152,59 @@ func (d DotSql) PrepareContext(ctx context.Context, db PreparerContext, name str
 		return nil, err
 	}
 
-	return db.PrepareContext(ctx, query)
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return stmt, nil
 }
 
 // Query is a wrapper for database/sql's Query(), using dotsql named query.
 func (d DotSql) Query(db Queryer, name string, args ...interface{}) (*sql.Rows, error) {
-	query, err := d.lookupQuery(name, d.data)
+	if err := d.checkCommand(name, "Query"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupArgs(name, args)
 	if err != nil {
 		return nil, err
 	}
 
-	return db.Query(query, args...)
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return rows, nil
 }
 
 // QueryContext is a wrapper for database/sql's QueryContext(), using dotsql named query.
 func (d DotSql) QueryContext(ctx context.Context, db QueryerContext, name string, args ...interface{}) (*sql.Rows, error) {
-	query, err := d.lookupQuery(name, d.data)
+	if err := d.checkCommand(name, "QueryContext"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupArgs(name, args)
 	if err != nil {
 		return nil, err
 	}
 
-	return db.QueryContext(ctx, query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return rows, nil
 }
 
 // QueryRow is a wrapper for database/sql's QueryRow(), using dotsql named query.
 func (d DotSql) QueryRow(db QueryRower, name string, args ...interface{}) (*sql.Row, error) {
-	query, err := d.lookupQuery(name, d.data)
+	if err := d.checkCommand(name, "QueryRow"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupArgs(name, args)
 	if err != nil {
 		return nil, err
 	}
@@ -136,7 +214,11 @@ func (d DotSql) QueryRow(db QueryRower, name string, args ...interface{}) (*sql.
 
 // QueryRowContext is a wrapper for database/sql's QueryRowContext(), using dotsql named query.
 func (d DotSql) QueryRowContext(ctx context.Context, db QueryRowerContext, name string, args ...interface{}) (*sql.Row, error) {
-	query, err := d.lookupQuery(name, d.data)
+	if err := d.checkCommand(name, "QueryRowContext"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupArgs(name, args)
 	if err != nil {
 		return nil, err
 	}
@@ -146,22 +228,40 @@ func (d DotSql) QueryRowContext(ctx context.Context, db QueryRowerContext, name
 
 // Exec is a wrapper for database/sql's Exec(), using dotsql named query.
 func (d DotSql) Exec(db Execer, name string, args ...interface{}) (sql.Result, error) {
-	query, err := d.lookupQuery(name, d.data)
+	if err := d.checkCommand(name, "Exec"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupArgs(name, args)
 	if err != nil {
 		return nil, err
 	}
 
-	return db.Exec(query, args...)
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return res, nil
 }
 
 // ExecContext is a wrapper for database/sql's ExecContext(), using dotsql named query.
 func (d DotSql) ExecContext(ctx context.Context, db ExecerContext, name string, args ...interface{}) (sql.Result, error) {
-	query, err := d.lookupQuery(name, d.data)
+	if err := d.checkCommand(name, "ExecContext"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupArgs(name, args)
 	if err != nil {
 		return nil, err
 	}
 
-	return db.ExecContext(ctx, query, args...)
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return res, nil
 }
 
 // Raw returns the query, everything after the --name tag
@@ -174,10 +274,55 @@ func (d DotSql) QueryMap() map[string]*template.Template {
 	return d.queries
 }
 
-// Load imports sql queries from any io.Reader.
-func Load(r io.Reader) (*DotSql, error) {
-	scanner := &Scanner{}
-	queries := scanner.Run(bufio.NewScanner(r))
+// Source returns the name of the file the query was loaded from, or an empty
+// string if the query was not loaded from a file.
+func (d DotSql) Source(name string) string {
+	return d.descriptors[name].Position.File
+}
+
+// Position returns where the query is defined. The boolean is false if the
+// query does not exist or was not loaded by dotsql.
+func (d DotSql) Position(name string) (Position, bool) {
+	query, ok := d.descriptors[name]
+	return query.Position, ok
+}
+
+// Lookup returns the descriptor of the query. The boolean is false if the
+// query does not exist.
+func (d DotSql) Lookup(name string) (Query, bool) {
+	if _, ok := d.queries[name]; !ok {
+		return Query{}, false
+	}
+	if query, ok := d.descriptors[name]; ok {
+		return query, true
+	}
+	return Query{Name: name}, true
+}
+
+// Load imports sql queries from any io.Reader. Lines may be of any length,
+// and errors returned by r are reported.
+func Load(r io.Reader, opts ...Option) (*DotSql, error) {
+	return load(r, "", newConfig(opts))
+}
+
+// load imports sql queries from r, recording file as their source.
+func load(r io.Reader, file string, cfg *config) (*DotSql, error) {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(nil, math.MaxInt)
+	lines.Split(ScanLines)
+
+	scanner := &Scanner{
+		File:       file,
+		Duplicates: cfg.duplicates,
+		Tags:       cfg.tags,
+		Preserve:   cfg.preserve,
+
+		BackslashEscapes: cfg.escapes,
+	}
+	queries := scanner.Run(lines)
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
 
 	templates := make(map[string]*template.Template)
 	for k, v := range queries {
@@ -188,41 +333,92 @@ func Load(r io.Reader) (*DotSql, error) {
 		templates[k] = tmpl
 	}
 
+	descriptors := scanner.Queries()
+	for name, q := range descriptors {
+		q.bind, q.escapes = cfg.bind, cfg.escapes
+		descriptors[name] = q
+	}
+
 	return &DotSql{
-		queries: templates,
+		queries:     templates,
+		descriptors: descriptors,
 	}, nil
 }
 
 // LoadFromFile imports SQL queries from the file.
-func LoadFromFile(sqlFile string) (*DotSql, error) {
+func LoadFromFile(sqlFile string, opts ...Option) (*DotSql, error) {
 	f, err := os.Open(sqlFile)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
 
-	return Load(f)
+	return load(f, sqlFile, newConfig(opts))
 }
 
 // LoadFromString imports SQL queries from the string.
-func LoadFromString(sql string) (*DotSql, error) {
+func LoadFromString(sql string, opts ...Option) (*DotSql, error) {
 	buf := bytes.NewBufferString(sql)
-	return Load(buf)
+	return Load(buf, opts...)
 }
 
 // Merge takes one or more *DotSql and merge its queries
 // It's in-order, so the last source will override queries with the same name
 // in the previous arguments if any.
+// Every query keeps the bind style it was loaded with.
 func Merge(dots ...*DotSql) *DotSql {
+	dot, _ := merge(DuplicateLastWins, dots)
+	return dot
+}
+
+// merge is like Merge, but resolves queries defined in more than one source
+// according to policy.
+func merge(policy DuplicatePolicy, dots []*DotSql) (*DotSql, error) {
 	queries := make(map[string]*template.Template)
+	descriptors := make(map[string]Query)
 
 	for _, dot := range dots {
 		for k, v := range dot.QueryMap() {
+			if _, ok := queries[k]; ok {
+				switch policy {
+				case DuplicateFirstWins:
+					continue
+				case DuplicateReject:
+					return nil, &DuplicateError{
+						Name:   k,
+						First:  descriptors[k].Position,
+						Second: dot.descriptors[k].Position,
+					}
+				}
+			}
 			queries[k] = v
+			if query, ok := dot.descriptors[k]; ok {
+				descriptors[k] = query
+			} else {
+				delete(descriptors, k)
+			}
 		}
 	}
 
 	return &DotSql{
-		queries: queries,
+		queries:     queries,
+		descriptors: descriptors,
+	}, nil
+}
+
+// withPrefix returns a copy of d with prefix prepended to every query name.
+func (d *DotSql) withPrefix(prefix string) *DotSql {
+	dot := &DotSql{
+		queries:     make(map[string]*template.Template, len(d.queries)),
+		descriptors: make(map[string]Query, len(d.descriptors)),
+		data:        d.data,
+	}
+	for k, v := range d.queries {
+		dot.queries[prefix+k] = v
+	}
+	for k, v := range d.descriptors {
+		v.Name = prefix + k
+		dot.descriptors[prefix+k] = v
 	}
+	return dot
 }
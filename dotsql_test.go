@@ -718,6 +718,40 @@ func TestMergeTakesPresecendeFromLastArgument(t *testing.T) {
 	}
 }
 
+func TestMergeKeepsBindStyle(t *testing.T) {
+	a, err := LoadFromString("--name: query-a\nSELECT * FROM a WHERE id = ?", WithBindStyle(BindDollar))
+	failIfError(t, err)
+
+	b, err := LoadFromString("--name: query-b\nSELECT * FROM b WHERE id = ?", WithBindStyle(BindDollar), BackslashEscapes())
+	failIfError(t, err)
+
+	c, err := LoadFromString("--name: query-c\nSELECT * FROM c WHERE id = ?")
+	failIfError(t, err)
+
+	x := Merge(a, c, b)
+	expected := map[string]string{
+		"query-a": "SELECT * FROM a WHERE id = $1",
+		"query-b": "SELECT * FROM b WHERE id = $1",
+		"query-c": "SELECT * FROM c WHERE id = ?",
+	}
+	for name, query := range expected {
+		if got, _ := x.lookupQuery(name, nil); got != query {
+			t.Errorf("Merge() rendered %s as '%s', expected '%s'", name, got, query)
+		}
+	}
+	if !x.descriptors["query-b"].escapes || x.descriptors["query-a"].escapes {
+		t.Error("Merge() expected to keep the backslash escapes of query-b only")
+	}
+
+	y := x.WithBindStyle(BindAt)
+	if got, _ := y.lookupQuery("query-c", nil); got != "SELECT * FROM c WHERE id = @p1" {
+		t.Errorf("WithBindStyle() rendered query-c as '%s'", got)
+	}
+	if got, _ := x.lookupQuery("query-c", nil); got != "SELECT * FROM c WHERE id = ?" {
+		t.Errorf("WithBindStyle() modified the original, which renders query-c as '%s'", got)
+	}
+}
+
 func TestTemplatesFailQuickly(t *testing.T) {
 	_, err := LoadFromString("-- name: bad-query\nSELECT * FROM {{if .user}}users{{else}}clients")
 	expectedErr := "template: bad-query:1: unexpected EOF"
@@ -727,3 +761,164 @@ func TestTemplatesFailQuickly(t *testing.T) {
 		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
 	}
 }
+
+func TestPosition(t *testing.T) {
+	dot, err := LoadFromFile("./test_schema.sql")
+	failIfError(t, err)
+
+	pos, ok := dot.Position("create-user")
+	if !ok {
+		t.Fatal("Position() expected to find 'create-user'")
+	}
+	expected := Position{File: "./test_schema.sql", StartLine: 9, EndLine: 10}
+	if pos != expected {
+		t.Errorf("Position() == %+v, expected %+v", pos, expected)
+	}
+
+	if _, ok := dot.Position("non-existent"); ok {
+		t.Error("Position() expected not to find 'non-existent'")
+	}
+}
+
+func TestErrorsIncludePosition(t *testing.T) {
+	dot, err := LoadFromString("-- name: select\nSELECT * FROM users\n\n-- name: bad\nSELECT {{index .x 1}}")
+	failIfError(t, err)
+
+	_, err = dot.Raw("bad")
+	failIfNotError(t, err)
+	if err != nil && !strings.HasPrefix(err.Error(), `dotsql: "bad" (line 4-5): error parsing template:`) {
+		t.Errorf("unexpected error '%v'", err)
+	}
+
+	criticalError := errors.New("critical error")
+	q := &ExecerMock{
+		ExecFunc: func(_ string, _ ...interface{}) (sql.Result, error) {
+			return nil, criticalError
+		},
+	}
+	_, err = dot.Exec(q, "select")
+	expectedErr := `dotsql: "select" (line 1-2): critical error`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+	}
+	if !errors.Is(err, criticalError) {
+		t.Error("error expected to wrap the driver error")
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func TestLoadReportsReadErrors(t *testing.T) {
+	dot, err := Load(failingReader{})
+	failIfNotError(t, err)
+	if dot != nil {
+		t.Error("dotsql instance expected to be nil, got non-nil")
+	}
+}
+
+func TestLoadLongLines(t *testing.T) {
+	expectedQuery := "INSERT INTO blobs (data) VALUES ('" + strings.Repeat("x", 4<<20) + "')"
+
+	dot, err := LoadFromString("-- name: insert-blob\n" + expectedQuery + "\n\n-- name: select\nSELECT 1")
+	failIfError(t, err)
+
+	got, err := dot.Raw("insert-blob")
+	failIfError(t, err)
+	if got != expectedQuery {
+		t.Errorf("Raw() returned a %d bytes query, expected %d bytes", len(got), len(expectedQuery))
+	}
+
+	if _, err := dot.Raw("select"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLoadStrict(t *testing.T) {
+	sqlFile := "-- name: query\nSELECT * FROM a\n-- name: query\nSELECT * FROM b"
+
+	dot, err := LoadFromString(sqlFile)
+	failIfError(t, err)
+	if got := extractTemplate(t, *dot, "query"); got != "SELECT * FROM b" {
+		t.Errorf("Raw() == '%s', expected '%s'", got, "SELECT * FROM b")
+	}
+
+	dot, err = LoadFromString(sqlFile, Strict())
+	if dot != nil {
+		t.Error("dotsql instance expected to be nil, got non-nil")
+	}
+	var dupErr *DuplicateError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("expected *DuplicateError, got '%v'", err)
+	}
+	if dupErr.Name != "query" || dupErr.First.StartLine != 1 || dupErr.Second.StartLine != 3 {
+		t.Errorf("unexpected error %+v", dupErr)
+	}
+}
+
+func TestLoadBackslashEscapes(t *testing.T) {
+	sqlFile := "-- name: q1\nINSERT INTO t VALUES ('it\\'s ?', ?)\n\n-- name: q2\nSELECT 2 # don't\n\n-- name: q3\nSELECT 3\n"
+
+	_, err := LoadFromString("-- name: q1\nSELECT 1 # don't\n\n-- name: q2\nSELECT 2\n")
+	failIfNotError(t, err)
+
+	dot, err := LoadFromString(sqlFile, BackslashEscapes())
+	failIfError(t, err)
+	if got := len(dot.QueryMap()); got != 3 {
+		t.Errorf("QueryMap() has %d queries, expected 3", got)
+	}
+	if got := dot.NumInput("q1"); got != 1 {
+		t.Errorf("NumInput(q1) == %d, expected 1", got)
+	}
+}
+
+func TestLoadPreserveFormatting(t *testing.T) {
+	expectedQuery := "SELECT *\n  FROM users\n\n  WHERE note = '  indented\n\n  text'\n"
+
+	dot, err := LoadFromString("-- name: select\n"+expectedQuery, PreserveFormatting())
+	failIfError(t, err)
+
+	if got := extractTemplate(t, *dot, "select"); got != expectedQuery {
+		t.Errorf("Raw() == %q, expected %q", got, expectedQuery)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	dot, err := LoadFromString("-- name: users-report\n-- timeout: 2s\nSELECT * FROM users")
+	failIfError(t, err)
+
+	query, ok := dot.Lookup("users-report")
+	if !ok {
+		t.Fatal("Lookup() expected to find 'users-report'")
+	}
+	if query.Name != "users-report" || query.Metadata["timeout"] != "2s" || query.Position.StartLine != 1 {
+		t.Errorf("unexpected query descriptor %+v", query)
+	}
+	if got := extractTemplate(t, *dot, "users-report"); got != "SELECT * FROM users" {
+		t.Errorf("Raw() == '%s', expected '%s'", got, "SELECT * FROM users")
+	}
+
+	if _, ok := dot.Lookup("non-existent"); ok {
+		t.Error("Lookup() expected not to find 'non-existent'")
+	}
+}
+
+func TestLookupDescription(t *testing.T) {
+	sqlFile := "-- name: a\nSELECT 1\n\n-- Counts users.\n-- name: b\nSELECT count(*) FROM users\n"
+
+	for _, opts := range [][]Option{nil, {PreserveFormatting()}} {
+		dot, err := LoadFromString(sqlFile, opts...)
+		failIfError(t, err)
+
+		query, _ := dot.Lookup("b")
+		if query.Description != "Counts users." {
+			t.Errorf("Lookup().Description == %q, expected %q", query.Description, "Counts users.")
+		}
+		if got := strings.TrimSpace(extractTemplate(t, *dot, "a")); got != "SELECT 1" {
+			t.Errorf("Raw() == %q, expected %q", got, "SELECT 1")
+		}
+	}
+}
@@ -44,7 +44,7 @@ func ExampleLoadFromFile() {
 		panic(err)
 	}
 
-	if _, err := dot.Exec(db, "create-user", "user@example.com"); err != nil {
+	if _, err := dot.Exec(db, "create-user", "User Name", "user@example.com"); err != nil {
 		panic(err)
 	}
 
@@ -0,0 +1,118 @@
+package dotsql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// expands reports whether the query opted in to slice expansion with an
+// "-- expand: true" annotation.
+func (q Query) expands() bool {
+	expand, _ := strconv.ParseBool(q.Metadata["expand"])
+	return expand
+}
+
+// expandSlices replaces every ? placeholder of query whose argument is a
+// slice with as many placeholders as it has elements, and flattens those
+// elements into the returned arguments, so that "WHERE id IN (?)" can be run
+// with a []int. Arrays, byte slices and driver.Valuer implementations are
+// passed as they are.
+func expandSlices(query string, args []any, escapes bool) (string, []any, error) {
+	var b strings.Builder
+	expanded := make([]any, 0, len(args))
+	n := 0
+	for _, seg := range splitSQL(query, escapes) {
+		if !seg.code {
+			b.WriteString(seg.text)
+			continue
+		}
+
+		text := seg.text
+		for i := 0; i < len(text); i++ {
+			if text[i] != '?' {
+				b.WriteByte(text[i])
+				continue
+			}
+			if i+1 < len(text) && text[i+1] == '?' {
+				b.WriteString("??")
+				i++
+				continue
+			}
+
+			if n >= len(args) {
+				b.WriteByte('?')
+				continue
+			}
+			arg := args[n]
+			n++
+
+			v, ok := expandable(arg)
+			if !ok {
+				b.WriteByte('?')
+				expanded = append(expanded, arg)
+				continue
+			}
+			if v.Len() == 0 {
+				return "", nil, fmt.Errorf("empty slice passed as argument %d", n)
+			}
+			for j := 0; j < v.Len(); j++ {
+				if j > 0 {
+					b.WriteString(", ")
+				}
+				b.WriteByte('?')
+				expanded = append(expanded, v.Index(j).Interface())
+			}
+		}
+	}
+
+	return b.String(), append(expanded, args[n:]...), nil
+}
+
+// expandable returns arg as a reflect.Value if it is a slice to expand. As
+// with sqlx.In, arrays are single values, and so are byte slices, including
+// named ones such as json.RawMessage.
+func expandable(arg any) (reflect.Value, bool) {
+	if _, ok := arg.(driver.Valuer); ok {
+		return reflect.Value{}, false
+	}
+
+	v := reflect.ValueOf(arg)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return reflect.Value{}, false
+	}
+	return v, true
+}
+
+// lookupArgs is like lookupQuery, but also prepares args to be run with the
+// query, expanding slices if the query opted in, and returns an
+// *ArgCountError if they don't match its placeholders.
+func (d DotSql) lookupArgs(name string, args []any) (string, []any, error) {
+	query, err := d.renderQuery(name, d.data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return d.bindArgs(name, query, args)
+}
+
+// bindArgs prepares the rendered query and its args to be run, checking
+// that they match.
+func (d DotSql) bindArgs(name, query string, args []any) (string, []any, error) {
+	q := d.descriptors[name]
+	if q.expands() {
+		var err error
+		query, args, err = expandSlices(query, args, q.escapes)
+		if err != nil {
+			return "", nil, d.queryError(name, err)
+		}
+	}
+
+	if err := checkArgs(name, query, args, q.bind, q.escapes); err != nil {
+		return "", nil, err
+	}
+
+	return rebind(query, q.bind, q.escapes), args, nil
+}
@@ -0,0 +1,111 @@
+package dotsql
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpandSlices(t *testing.T) {
+	var tests = []struct {
+		query    string
+		args     []any
+		want     string
+		wantArgs []any
+	}{
+		{
+			"SELECT * FROM users WHERE id IN (?)",
+			[]any{[]int{1, 2, 3}},
+			"SELECT * FROM users WHERE id IN (?, ?, ?)",
+			[]any{1, 2, 3},
+		},
+		{
+			"SELECT * FROM users WHERE name = ? AND id IN (?) AND data ?? 'k' AND note <> '?' AND role IN (?)",
+			[]any{"foo", []int64{4, 5}, []string{"admin"}},
+			"SELECT * FROM users WHERE name = ? AND id IN (?, ?) AND data ?? 'k' AND note <> '?' AND role IN (?)",
+			[]any{"foo", int64(4), int64(5), "admin"},
+		},
+		{
+			"INSERT INTO blobs (data, at) VALUES (?, ?)",
+			[]any{[]byte("raw"), time.Time{}},
+			"INSERT INTO blobs (data, at) VALUES (?, ?)",
+			[]any{[]byte("raw"), time.Time{}},
+		},
+		{
+			"SELECT * FROM users WHERE uuid = ?",
+			[]any{[16]byte{1}},
+			"SELECT * FROM users WHERE uuid = ?",
+			[]any{[16]byte{1}},
+		},
+		{
+			"INSERT INTO docs (body) VALUES (?)",
+			[]any{json.RawMessage(`{"a":1}`)},
+			"INSERT INTO docs (body) VALUES (?)",
+			[]any{json.RawMessage(`{"a":1}`)},
+		},
+		{
+			"SELECT ?",
+			[]any{1, 2},
+			"SELECT ?",
+			[]any{1, 2},
+		},
+	}
+
+	for _, c := range tests {
+		got, args, err := expandSlices(c.query, c.args, false)
+		failIfError(t, err)
+		if got != c.want {
+			t.Errorf("expandSlices(%q) == %q, expected %q", c.query, got, c.want)
+		}
+		if !reflect.DeepEqual(args, c.wantArgs) {
+			t.Errorf("expandSlices(%q) args == %v, expected %v", c.query, args, c.wantArgs)
+		}
+	}
+
+	_, _, err := expandSlices("SELECT * FROM users WHERE id IN (?)", []any{[]int{}}, false)
+	failIfNotError(t, err)
+}
+
+func TestQueryExpandsSlices(t *testing.T) {
+	dot, err := LoadFromString(`
+-- name: find-by-ids
+-- expand: true
+SELECT * FROM users WHERE id IN (?)
+
+-- name: find-by-tags
+SELECT * FROM users WHERE tags = ?
+
+-- name: find-by-names
+-- expand: true
+SELECT * FROM users WHERE name IN (:names) AND active = :active
+`, WithBindStyle(BindDollar))
+	failIfError(t, err)
+
+	query, args, err := dot.lookupArgs("find-by-ids", []any{[]int{1, 2}})
+	failIfError(t, err)
+	if expected := "SELECT * FROM users WHERE id IN ($1, $2)"; query != expected {
+		t.Errorf("lookupArgs() == %q, expected %q", query, expected)
+	}
+	if !reflect.DeepEqual(args, []any{1, 2}) {
+		t.Errorf("lookupArgs() args == %v, expected %v", args, []any{1, 2})
+	}
+
+	query, args, err = dot.lookupArgs("find-by-tags", []any{[]string{"a", "b"}})
+	failIfError(t, err)
+	if expected := "SELECT * FROM users WHERE tags = $1"; query != expected {
+		t.Errorf("lookupArgs() == %q, expected %q", query, expected)
+	}
+	if len(args) != 1 {
+		t.Errorf("lookupArgs() args == %v, expected the slice not to be expanded", args)
+	}
+
+	query, args, err = dot.lookupNamed("find-by-names", map[string]any{"names": []string{"a", "b"}, "active": true})
+	failIfError(t, err)
+	if expected := "SELECT * FROM users WHERE name IN ($1, $2) AND active = $3"; query != expected {
+		t.Errorf("lookupNamed() == %q, expected %q", query, expected)
+	}
+	if !reflect.DeepEqual(args, []any{"a", "b", true}) {
+		t.Errorf("lookupNamed() args == %v, expected %v", args, []any{"a", "b", true})
+	}
+}
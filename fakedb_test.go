@@ -0,0 +1,86 @@
+package dotsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+)
+
+// fakeDB returns a database whose queries all return the given columns and
+// rows.
+func fakeDB(t *testing.T, columns []string, rows ...[]driver.Value) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(fakeConnector{columns: columns, rows: rows})
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+type fakeConnector struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return fakeConn(c), nil
+}
+
+func (c fakeConnector) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn fakeConnector
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) {
+	return fakeStmt(c), nil
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type fakeStmt fakeConnector
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(len(s.rows)), nil
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{columns: s.columns, rows: s.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,170 @@
+package dotsql
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// LoadFromFS imports SQL queries from every file in fsys matching one of the
+// given glob patterns. Patterns use path.Match syntax with the addition of
+// "**", which matches any number of directories (e.g. "queries/**/*.sql").
+// If no pattern is given, every .sql file in fsys is loaded.
+//
+// Files are loaded in lexical order and merged as in Merge, so a query
+// defined in more than one file takes its body from the last one.
+// Source reports the file each query came from.
+func LoadFromFS(fsys fs.FS, patterns ...string) (*DotSql, error) {
+	return LoadFromFSWith(fsys, nil, patterns...)
+}
+
+// LoadFromFSWith is like LoadFromFS, loading every file with opts. A query
+// defined in more than one file is handled as WithDuplicates or Strict say.
+func LoadFromFSWith(fsys fs.FS, opts []Option, patterns ...string) (*DotSql, error) {
+	cfg := newConfig(opts)
+	if len(patterns) == 0 {
+		patterns = []string{"**/*.sql"}
+	}
+	for _, pattern := range patterns {
+		if _, err := matchGlob(pattern, "."); err != nil {
+			return nil, fmt.Errorf("dotsql: invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	var dots []*DotSql
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		matched, err := matchAny(patterns, name)
+		if err != nil || !matched {
+			return err
+		}
+
+		dot, err := loadFromFSFile(fsys, name, cfg)
+		if err != nil {
+			return err
+		}
+		dots = append(dots, dot)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merge(cfg.duplicates, dots)
+}
+
+// LoadFromDir imports SQL queries from every .sql file under the root
+// directory of fsys, prefixing each query name with the path of its directory
+// relative to root. A query named find-by-email in root/users/billing.sql is
+// loaded as "users.find-by-email", while queries in files directly under
+// root keep their names. Use WithSeparator to join path elements with
+// something other than ".", and WithDuplicates or Strict to control how
+// queries ending up with the same name are handled.
+//
+// To load a directory from disk use os.DirFS:
+//
+//	dot, err := dotsql.LoadFromDir(os.DirFS("sql"), ".")
+func LoadFromDir(fsys fs.FS, root string, opts ...Option) (*DotSql, error) {
+	cfg := newConfig(opts)
+
+	var dots []*DotSql
+	err := fs.WalkDir(fsys, root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || path.Ext(name) != ".sql" {
+			return nil
+		}
+
+		dot, err := loadFromFSFile(fsys, name, cfg)
+		if err != nil {
+			return err
+		}
+
+		dir := path.Dir(name)
+		if root != "." {
+			dir = strings.TrimPrefix(strings.TrimPrefix(dir, root), "/")
+		}
+		if dir != "" && dir != "." {
+			prefix := strings.ReplaceAll(dir, "/", cfg.separator) + cfg.separator
+			dot = dot.withPrefix(prefix)
+		}
+
+		dots = append(dots, dot)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merge(cfg.duplicates, dots)
+}
+
+// loadFromFSFile imports SQL queries from the file name in fsys.
+func loadFromFSFile(fsys fs.FS, name string, cfg *config) (*DotSql, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dot, err := load(f, name, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return dot, nil
+}
+
+func matchAny(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := matchGlob(pattern, name)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// matchGlob reports whether name matches pattern, where pattern is a
+// slash-separated path.Match pattern in which a "**" element matches zero or
+// more path elements.
+func matchGlob(pattern, name string) (bool, error) {
+	elems := strings.Split(pattern, "/")
+	for _, elem := range elems {
+		if _, err := path.Match(elem, ""); err != nil {
+			return false, err
+		}
+	}
+	return matchElems(elems, strings.Split(name, "/")), nil
+}
+
+// matchElems matches path elements against an already validated pattern.
+func matchElems(pattern, elems []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(elems); i++ {
+				if matchElems(pattern[1:], elems[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(elems) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], elems[0]); !matched {
+			return false
+		}
+		pattern, elems = pattern[1:], elems[1:]
+	}
+	return len(elems) == 0
+}
@@ -0,0 +1,181 @@
+package dotsql
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMatchGlob(t *testing.T) {
+	var tests = []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.sql", "users.sql", true},
+		{"*.sql", "queries/users.sql", false},
+		{"queries/*.sql", "queries/users.sql", true},
+		{"queries/**/*.sql", "queries/users.sql", true},
+		{"queries/**/*.sql", "queries/a/b/users.sql", true},
+		{"queries/**/*.sql", "other/users.sql", false},
+		{"**/*.sql", "users.sql", true},
+		{"**", "a/b/c.txt", true},
+		{"**/*.sql", "users.txt", false},
+	}
+
+	for _, c := range tests {
+		got, err := matchGlob(c.pattern, c.name)
+		failIfError(t, err)
+		if got != c.want {
+			t.Errorf("matchGlob(%q, %q) == %v, expected %v", c.pattern, c.name, got, c.want)
+		}
+	}
+
+	_, err := matchGlob("queries/[", "queries/users.sql")
+	failIfNotError(t, err)
+}
+
+func TestLoadFromFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"queries/users.sql":         {Data: []byte("-- name: find-users\nSELECT * FROM users")},
+		"queries/billing/plans.sql": {Data: []byte("-- name: find-plans\nSELECT * FROM plans")},
+		"queries/billing/README.md": {Data: []byte("-- name: not-a-query\nSELECT 1")},
+		"queries/v2/users.sql":      {Data: []byte("-- name: find-users\nSELECT * FROM users WHERE active")},
+		"other.sql":                 {Data: []byte("-- name: other\nSELECT 1")},
+	}
+
+	dot, err := LoadFromFS(fsys, "queries/**/*.sql")
+	failIfError(t, err)
+
+	expectedSources := map[string]string{
+		"find-users": "queries/v2/users.sql",
+		"find-plans": "queries/billing/plans.sql",
+	}
+	if len(dot.QueryMap()) != len(expectedSources) {
+		t.Errorf("QueryMap() len (%d) differ from expected (%d)", len(dot.QueryMap()), len(expectedSources))
+	}
+	for name, source := range expectedSources {
+		if got := dot.Source(name); got != source {
+			t.Errorf("Source(%q) == %q, expected %q", name, got, source)
+		}
+	}
+
+	expectedQuery := "SELECT * FROM users WHERE active"
+	if got := extractTemplate(t, *dot, "find-users"); got != expectedQuery {
+		t.Errorf("Raw() == %q, expected %q", got, expectedQuery)
+	}
+
+	t.Run("default pattern", func(t *testing.T) {
+		dot, err := LoadFromFS(fsys)
+		failIfError(t, err)
+		if len(dot.QueryMap()) != 3 {
+			t.Errorf("QueryMap() len (%d) differ from expected (%d)", len(dot.QueryMap()), 3)
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		_, err := LoadFromFSWith(fsys, []Option{Strict()}, "queries/**/*.sql")
+		var dupErr *DuplicateError
+		if !errors.As(err, &dupErr) || dupErr.Name != "find-users" {
+			t.Errorf("expected a *DuplicateError for find-users, got '%v'", err)
+		}
+
+		dot, err := LoadFromFSWith(fsys, []Option{WithBindStyle(BindDollar), PreserveFormatting()}, "other.sql")
+		failIfError(t, err)
+		if bind := dot.descriptors["other"].bind; bind != BindDollar {
+			t.Errorf("bind style == %v, expected %v", bind, BindDollar)
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		dot, err := LoadFromFS(fsys, "[")
+		failIfNotError(t, err)
+		if dot != nil {
+			t.Error("dotsql instance expected to be nil, got non-nil")
+		}
+	})
+
+	t.Run("template error reports file", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"bad.sql": {Data: []byte("-- name: bad\nSELECT {{if .x}}")},
+		}
+		_, err := LoadFromFS(fsys)
+		failIfNotError(t, err)
+		expectedErr := "bad.sql: template: bad:1: unexpected EOF"
+		if err != nil && err.Error() != expectedErr {
+			t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+		}
+	})
+}
+
+func TestLoadFromDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/common.sql":            {Data: []byte("-- name: ping\nSELECT 1")},
+		"sql/users/queries.sql":     {Data: []byte("-- name: find-by-email\nSELECT * FROM users WHERE email = ?")},
+		"sql/billing/queries.sql":   {Data: []byte("-- name: find-by-email\nSELECT * FROM invoices WHERE email = ?")},
+		"sql/billing/plans/all.sql": {Data: []byte("-- name: all\nSELECT * FROM plans")},
+		"sql/billing/notes.txt":     {Data: []byte("-- name: not-a-query\nSELECT 1")},
+	}
+
+	t.Run("default separator", func(t *testing.T) {
+		dot, err := LoadFromDir(fsys, "sql")
+		failIfError(t, err)
+
+		expectedSources := map[string]string{
+			"ping":                  "sql/common.sql",
+			"users.find-by-email":   "sql/users/queries.sql",
+			"billing.find-by-email": "sql/billing/queries.sql",
+			"billing.plans.all":     "sql/billing/plans/all.sql",
+		}
+		if len(dot.QueryMap()) != len(expectedSources) {
+			t.Errorf("QueryMap() len (%d) differ from expected (%d)", len(dot.QueryMap()), len(expectedSources))
+		}
+		for name, source := range expectedSources {
+			if got := dot.Source(name); got != source {
+				t.Errorf("Source(%q) == %q, expected %q", name, got, source)
+			}
+		}
+	})
+
+	t.Run("custom separator", func(t *testing.T) {
+		dot, err := LoadFromDir(fsys, "sql", WithSeparator("/"))
+		failIfError(t, err)
+
+		if _, err := dot.Raw("billing/plans/all"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("root directory", func(t *testing.T) {
+		dot, err := LoadFromDir(fsys, ".")
+		failIfError(t, err)
+
+		if _, err := dot.Raw("sql.users.find-by-email"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := LoadFromDir(fsys, "missing")
+		failIfNotError(t, err)
+	})
+}
+
+func TestLoadFromDirDuplicates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/a.sql": {Data: []byte("-- name: ping\nSELECT 'a'")},
+		"sql/b.sql": {Data: []byte("-- name: ping\nSELECT 'b'")},
+	}
+
+	dot, err := LoadFromDir(fsys, "sql", WithDuplicates(DuplicateFirstWins))
+	failIfError(t, err)
+	if got := dot.Source("ping"); got != "sql/a.sql" {
+		t.Errorf("Source(%q) == %q, expected %q", "ping", got, "sql/a.sql")
+	}
+
+	_, err = LoadFromDir(fsys, "sql", Strict())
+	expectedErr := `dotsql: query "ping" defined twice, at sql/a.sql:1-2 and sql/b.sql:1-2`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+	}
+}
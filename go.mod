@@ -1,5 +1,13 @@
 module github.com/qustavo/dotsql
 
-go 1.18
+go 1.23.0
 
-require github.com/mxk/go-sqlite v0.0.0-20140611214908-167da9432e1f
+require (
+	github.com/mxk/go-sqlite v0.0.0-20140611214908-167da9432e1f
+	golang.org/x/tools v0.31.0
+)
+
+require (
+	golang.org/x/mod v0.24.0 // indirect
+	golang.org/x/sync v0.12.0 // indirect
+)
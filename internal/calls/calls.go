@@ -0,0 +1,112 @@
+// Package calls recognizes the calls to dotsql taking a query name, for the
+// tools checking them.
+package calls
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// DotsqlPath is the import path of dotsql.
+const DotsqlPath = "github.com/qustavo/dotsql"
+
+// Call describes the arguments of a function or method taking a query name.
+type Call struct {
+	Name int // index of the query name
+	Args int // index of the query arguments, or -1 if there are none to count
+	Dot  int // index of the *DotSql argument, or -1 for methods called on it
+}
+
+var methods = map[string]Call{
+	"Prepare":              {1, -1, -1},
+	"PrepareContext":       {2, -1, -1},
+	"Query":                {1, 2, -1},
+	"QueryContext":         {2, 3, -1},
+	"QueryRow":             {1, 2, -1},
+	"QueryRowContext":      {2, 3, -1},
+	"Exec":                 {1, 2, -1},
+	"ExecContext":          {2, 3, -1},
+	"QueryMaps":            {2, 3, -1},
+	"QueryNamed":           {1, -1, -1},
+	"QueryNamedContext":    {2, -1, -1},
+	"QueryRowNamed":        {1, -1, -1},
+	"QueryRowNamedContext": {2, -1, -1},
+	"ExecNamed":            {1, -1, -1},
+	"ExecNamedContext":     {2, -1, -1},
+	"Raw":                  {0, -1, -1},
+	"Source":               {0, -1, -1},
+	"Position":             {0, -1, -1},
+	"Lookup":               {0, -1, -1},
+	"NumInput":             {0, -1, -1},
+}
+
+var funcs = map[string]Call{
+	"Select": {3, 4, 1},
+	"Get":    {3, 4, 1},
+	"Rows":   {3, 4, 1},
+	"Scalar": {3, 4, 1},
+	"Exists": {3, 4, 1},
+}
+
+// Lookup returns how the function called by expr takes a query name, if it is
+// a DotSql method or a function of dotsql doing so.
+func Lookup(info *types.Info, expr *ast.CallExpr) (Call, bool) {
+	fn, ok := typeutil.Callee(info, expr).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != DotsqlPath {
+		return Call{}, false
+	}
+
+	recv := fn.Type().(*types.Signature).Recv()
+	if recv == nil {
+		c, ok := funcs[fn.Name()]
+		return c, ok
+	}
+	if !IsDotSql(recv.Type()) {
+		return Call{}, false
+	}
+	c, ok := methods[fn.Name()]
+	return c, ok
+}
+
+// QueryName returns the query name passed to expr, if it calls dotsql with a
+// constant one.
+func QueryName(info *types.Info, expr *ast.CallExpr) (string, Call, bool) {
+	c, ok := Lookup(info, expr)
+	if !ok || c.Name >= len(expr.Args) {
+		return "", Call{}, false
+	}
+
+	value := info.Types[expr.Args[c.Name]].Value
+	if value == nil || value.Kind() != constant.String {
+		return "", Call{}, false
+	}
+	return constant.StringVal(value), c, true
+}
+
+// DotSql returns the DotSql expr, calling a function described by c, is called
+// on or passed to.
+func (c Call) DotSql(expr *ast.CallExpr) ast.Expr {
+	if c.Dot >= 0 {
+		if c.Dot >= len(expr.Args) {
+			return nil
+		}
+		return expr.Args[c.Dot]
+	}
+	sel, ok := ast.Unparen(expr.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	return sel.X
+}
+
+// IsDotSql reports whether t is DotSql or a pointer to it.
+func IsDotSql(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Name() == "DotSql"
+}
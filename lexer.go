@@ -0,0 +1,244 @@
+package dotsql
+
+import "strings"
+
+type lexMode int
+
+const (
+	lexCode       lexMode = iota // statement level
+	lexString                    // inside '...'
+	lexIdentifier                // inside "..."
+	lexComment                   // inside /* ... */
+	lexDollar                    // inside $tag$ ... $tag$
+)
+
+func (m lexMode) String() string {
+	switch m {
+	case lexString:
+		return "string literal"
+	case lexIdentifier:
+		return "quoted identifier"
+	case lexComment:
+		return "block comment"
+	case lexDollar:
+		return "dollar quoted string"
+	}
+	return "statement"
+}
+
+// lexer tracks the SQL constructs that may span several lines: quoted
+// strings and identifiers, block comments and PostgreSQL dollar quoting.
+type lexer struct {
+	mode  lexMode
+	depth int    // nesting level of block comments
+	tag   string // delimiter of the dollar quoted string
+	// escapes makes backslashes escape the next character in quoted
+	// strings, and # start a line comment, as MySQL does by default.
+	escapes bool
+	// escaped is set inside a PostgreSQL E'...' string, where backslashes
+	// are escapes regardless of escapes.
+	escaped bool
+}
+
+// inCode reports whether the lexer is at statement level.
+func (l *lexer) inCode() bool {
+	return l.mode == lexCode
+}
+
+// feed advances the lexer over s.
+func (l *lexer) feed(s string) {
+	for i := 0; i < len(s); {
+		i, _ = l.next(s, i)
+	}
+}
+
+// segment is a piece of SQL text that is either code or part of a literal or
+// a comment.
+type segment struct {
+	text string
+	code bool
+}
+
+// splitSQL splits query into alternating code and non-code segments, which
+// concatenated give back query. If escapes is set, backslashes escape
+// characters in all quoted strings.
+func splitSQL(query string, escapes bool) []segment {
+	var segments []segment
+	l := &lexer{escapes: escapes}
+	for i := 0; i < len(query); {
+		j, code := l.next(query, i)
+		if n := len(segments); n > 0 && segments[n-1].code == code {
+			segments[n-1].text = query[i-len(segments[n-1].text) : j]
+		} else {
+			segments = append(segments, segment{text: query[i:j], code: code})
+		}
+		i = j
+	}
+	return segments
+}
+
+// next consumes the token of s starting at i. It returns the index following
+// the token, and whether the token is code as opposed to part of a literal or
+// a comment. Code tokens are a single byte long.
+func (l *lexer) next(s string, i int) (int, bool) {
+	switch l.mode {
+	case lexString:
+		return l.closeQuote(s, i, '\''), false
+	case lexIdentifier:
+		return l.closeQuote(s, i, '"'), false
+	case lexComment:
+		return l.closeComment(s, i), false
+	case lexDollar:
+		end := strings.Index(s[i:], l.tag)
+		if end < 0 {
+			return len(s), false
+		}
+		l.mode = lexCode
+		return i + end + len(l.tag), false
+	}
+
+	switch {
+	case strings.HasPrefix(s[i:], "--") || l.escapes && s[i] == '#':
+		end := strings.IndexByte(s[i:], '\n')
+		if end < 0 {
+			return len(s), false
+		}
+		return i + end, false
+	case strings.HasPrefix(s[i:], "/*"):
+		l.mode, l.depth = lexComment, 1
+		return l.closeComment(s, i+2), false
+	case (s[i] == 'E' || s[i] == 'e') && strings.HasPrefix(s[i+1:], "'") && (i == 0 || !isIdentChar(s[i-1])):
+		l.mode, l.escaped = lexString, true
+		return l.closeQuote(s, i+2, '\''), false
+	case s[i] == '\'':
+		l.mode, l.escaped = lexString, false
+		return l.closeQuote(s, i+1, '\''), false
+	case s[i] == '"':
+		l.mode, l.escaped = lexIdentifier, false
+		return l.closeQuote(s, i+1, '"'), false
+	case s[i] == '$':
+		if tag := dollarTag(s, i); tag != "" {
+			l.mode, l.tag = lexDollar, tag
+			return i + len(tag), false
+		}
+	}
+	return i + 1, true
+}
+
+// closeQuote consumes s from i up to and including the closing quote, going
+// back to statement level if it is found. Doubled quotes are escapes, and so
+// are backslashes in E'...' strings or if l.escapes is set.
+func (l *lexer) closeQuote(s string, i int, quote byte) int {
+	for ; i < len(s); i++ {
+		if s[i] == '\\' && (l.escapes || l.escaped) {
+			i++
+			continue
+		}
+		if s[i] != quote {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == quote {
+			i++
+			continue
+		}
+		l.mode = lexCode
+		return i + 1
+	}
+	return len(s)
+}
+
+// closeComment consumes s from i up to and including the end of the block
+// comment, which may contain nested block comments.
+func (l *lexer) closeComment(s string, i int) int {
+	for ; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "/*"):
+			l.depth++
+			i++
+		case strings.HasPrefix(s[i:], "*/"):
+			i++
+			if l.depth--; l.depth == 0 {
+				l.mode = lexCode
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+// dollarTag returns the dollar quote delimiter ($$ or $tag$) starting at i,
+// or an empty string if there is none. Positional parameters such as $1 and
+// identifiers containing $ are not delimiters.
+func dollarTag(s string, i int) string {
+	if i > 0 && isIdentChar(s[i-1]) {
+		return ""
+	}
+	for j := i + 1; j < len(s); j++ {
+		switch {
+		case s[j] == '$':
+			return s[i : j+1]
+		case j == i+1 && !isIdentStart(s[j]), !isIdentChar(s[j]):
+			return ""
+		}
+	}
+	return ""
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || '0' <= c && c <= '9' || c == '$'
+}
+
+// interpolatesValue reports whether a template action of query prints data
+// inside a string literal, or right after a comparison operator, where SQL
+// expects a value that should rather be passed as an argument.
+func interpolatesValue(query string, escapes bool) bool {
+	var code string
+	for _, seg := range splitSQL(query, escapes) {
+		if !seg.code {
+			if strings.ContainsRune("'$Ee", rune(seg.text[0])) {
+				if printsData(seg.text) {
+					return true
+				}
+			}
+			continue
+		}
+
+		text := seg.text
+		for {
+			i := strings.Index(text, "{{")
+			if i < 0 {
+				break
+			}
+			before := strings.ToUpper(strings.TrimRight(code+text[:i], " \t\r\n"))
+			if printsData(text[i:]) && (strings.HasSuffix(before, "=") ||
+				strings.HasSuffix(before, "<") || strings.HasSuffix(before, ">") ||
+				strings.HasSuffix(before, " LIKE")) {
+				return true
+			}
+			code, text = "", text[i+2:]
+		}
+		code += text
+	}
+	return false
+}
+
+// printsData reports whether s starts with, or for literals contains, a
+// template action printing a field or a variable, as opposed to a control
+// action such as {{if .x}}.
+func printsData(s string) bool {
+	for {
+		i := strings.Index(s, "{{")
+		if i < 0 {
+			return false
+		}
+		action := strings.TrimLeft(strings.TrimPrefix(s[i+2:], "-"), " \t")
+		if strings.HasPrefix(action, ".") || strings.HasPrefix(action, "$") {
+			return true
+		}
+		s = s[i+2:]
+	}
+}
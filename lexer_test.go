@@ -0,0 +1,100 @@
+package dotsql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLexer(t *testing.T) {
+	var tests = []struct {
+		lines []string
+		want  lexMode
+	}{
+		{[]string{"SELECT 1"}, lexCode},
+		{[]string{"SELECT 'it''s'"}, lexCode},
+		{[]string{"SELECT 'multi", "line"}, lexString},
+		{[]string{"SELECT 'multi", "line'"}, lexCode},
+		{[]string{`SELECT "weird`}, lexIdentifier},
+		{[]string{"SELECT 1 -- it's a comment"}, lexCode},
+		{[]string{"SELECT /* comment"}, lexComment},
+		{[]string{"SELECT /* outer /* inner */", "still a comment"}, lexComment},
+		{[]string{"SELECT /* outer /* inner */", "*/ 1"}, lexCode},
+		{[]string{"AS $$", "SELECT 1"}, lexDollar},
+		{[]string{"AS $$", "SELECT 1", "$$ LANGUAGE sql"}, lexCode},
+		{[]string{"AS $body$", "SELECT $$", "$body$"}, lexCode},
+		{[]string{"SELECT $1, $2"}, lexCode},
+		{[]string{"SELECT a$b$ FROM t"}, lexCode},
+		{[]string{`SELECT 'a\'`}, lexCode},
+		{[]string{`SELECT E'it\'s'`}, lexCode},
+		{[]string{`SELECT e'a\\', 'b\'`}, lexCode},
+		{[]string{`SELECT E'it\'s`}, lexString},
+		{[]string{`SELECT type'it\'s'`}, lexString},
+	}
+
+	for _, c := range tests {
+		l := &lexer{}
+		for _, line := range c.lines {
+			l.feed(line)
+		}
+		if l.mode != c.want {
+			t.Errorf("lexer mode after %q == %d, expected %d", c.lines, l.mode, c.want)
+		}
+	}
+}
+
+func TestSplitSQL(t *testing.T) {
+	query := "SELECT 'a:b', x::text /* :c */ FROM t -- :d\nWHERE e = :e AND f = $$:f$$"
+	expected := []segment{
+		{"SELECT ", true},
+		{"'a:b'", false},
+		{", x::text ", true},
+		{"/* :c */", false},
+		{" FROM t ", true},
+		{"-- :d", false},
+		{"\nWHERE e = :e AND f = ", true},
+		{"$$:f$$", false},
+	}
+
+	got := splitSQL(query, false)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("splitSQL(%q) == %+v, expected %+v", query, got, expected)
+	}
+
+	query = "SELECT 'it\\'s ?', \"a\\\"b\" FROM t # don't ?\nWHERE id = ?"
+	expected = []segment{
+		{"SELECT ", true},
+		{`'it\'s ?'`, false},
+		{", ", true},
+		{`"a\"b"`, false},
+		{" FROM t ", true},
+		{"# don't ?", false},
+		{"\nWHERE id = ?", true},
+	}
+
+	got = splitSQL(query, true)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("splitSQL(%q, true) == %+v, expected %+v", query, got, expected)
+	}
+}
+
+func TestInterpolatesValue(t *testing.T) {
+	tests := map[string]bool{
+		"SELECT * FROM users":                                     false,
+		"SELECT * FROM {{.table}}":                                false,
+		"SELECT * FROM users {{if .active}}WHERE active{{end}}":   false,
+		"SELECT * FROM users WHERE id = {{.id}}":                  true,
+		"SELECT * FROM users WHERE id >= {{- $id}}":               true,
+		"SELECT * FROM users WHERE name like {{.name}}":           true,
+		"SELECT * FROM users WHERE name = '{{.name}}'":            true,
+		"SELECT * FROM users WHERE name = $${{.name}}$$":          true,
+		"SELECT * FROM users -- id = {{.id}}":                     false,
+		`SELECT * FROM "{{.schema}}".users WHERE id = ?`:          false,
+		"SELECT * FROM users WHERE {{if .id}}id = {{.id}}{{end}}": true,
+		"SELECT * FROM users WHERE name = E'{{.name}}'":           true,
+	}
+	for query, want := range tests {
+		if got := interpolatesValue(query, false); got != want {
+			t.Errorf("interpolatesValue(%q) == %v, expected %v", query, got, want)
+		}
+	}
+}
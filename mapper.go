@@ -0,0 +1,74 @@
+package dotsql
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var fieldCache sync.Map // map[reflect.Type]map[string][]int
+
+// fieldsByName maps column and parameter names to the index of the fields of
+// the struct type t. Names come from the "db" struct tag, or default to the
+// lowercased field name; fields tagged `db:"-"` and unexported fields are
+// skipped. Fields of embedded structs are promoted, unless the embedded
+// struct is itself tagged.
+func fieldsByName(t reflect.Type) map[string][]int {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.(map[string][]int)
+	}
+
+	fields := make(map[string][]int)
+	collectFields(t, nil, fields)
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+func collectFields(t reflect.Type, index []int, fields map[string][]int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+		if field.Anonymous && tag == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectFields(ft, fieldIndex, fields)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := tag
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		// Shallower fields take precedence over promoted ones.
+		if existing, ok := fields[name]; !ok || len(existing) > len(fieldIndex) {
+			fields[name] = fieldIndex
+		}
+	}
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but returns false instead
+// of panicking when going through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
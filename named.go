@@ -0,0 +1,227 @@
+package dotsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// bindNamed rewrites the :name and @name parameters of query as ?
+// placeholders, and returns the matching values of arg in order. Parameters
+// inside string literals and comments, PostgreSQL casts (::) and variables
+// such as @@version are left untouched.
+func bindNamed(query string, arg any, escapes bool) (string, []any, error) {
+	lookup, err := namedValues(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var b strings.Builder
+	var args []any
+	for _, seg := range splitSQL(query, escapes) {
+		if !seg.code {
+			b.WriteString(seg.text)
+			continue
+		}
+
+		text := seg.text
+		for i := 0; i < len(text); i++ {
+			name := namedParameter(text, i)
+			if name == "" {
+				b.WriteByte(text[i])
+				continue
+			}
+
+			value, ok := lookup(name)
+			if !ok {
+				return "", nil, fmt.Errorf("missing value for named parameter %q", name)
+			}
+			args = append(args, value)
+			b.WriteByte('?')
+			i += len(name)
+		}
+	}
+
+	return b.String(), args, nil
+}
+
+// namedParameter returns the name of the :name or @name parameter starting
+// at text[i], or an empty string if there is none.
+func namedParameter(text string, i int) string {
+	c := text[i]
+	if c != ':' && c != '@' || i+1 >= len(text) || !isIdentStart(text[i+1]) {
+		return ""
+	}
+	if i > 0 && (text[i-1] == c || text[i-1] == ':' || isIdentChar(text[i-1])) {
+		return ""
+	}
+
+	j := i + 1
+	for j < len(text) && isIdentChar(text[j]) && text[j] != '$' {
+		j++
+	}
+	return text[i+1 : j]
+}
+
+// namedValues returns a function looking up named values in arg, which must
+// be a map with string keys, or a struct or pointer to struct whose fields
+// are matched as described in fieldsByName.
+func namedValues(arg any) (func(name string) (any, bool), error) {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		return func(name string) (any, bool) {
+			value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !value.IsValid() {
+				return nil, false
+			}
+			return value.Interface(), true
+		}, nil
+	case v.Kind() == reflect.Struct:
+		fields := fieldsByName(v.Type())
+		return func(name string) (any, bool) {
+			index, ok := fields[name]
+			if !ok {
+				return nil, false
+			}
+			field, ok := fieldByIndex(v, index)
+			if !ok {
+				return nil, true
+			}
+			return field.Interface(), true
+		}, nil
+	}
+
+	return nil, fmt.Errorf("named parameters must be bound from a map or a struct, got %T", arg)
+}
+
+// lookupNamed is like lookupQuery, with the named parameters of the query
+// bound to arg.
+func (d DotSql) lookupNamed(name string, arg any) (string, []any, error) {
+	query, err := d.renderQuery(name, d.data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	query, args, err := bindNamed(query, arg, d.descriptors[name].escapes)
+	if err != nil {
+		return "", nil, d.queryError(name, err)
+	}
+
+	return d.bindArgs(name, query, args)
+}
+
+// QueryNamed is like Query, but binds the :name and @name parameters of the
+// query to arg, a map with string keys or a struct whose fields are matched
+// by their "db" tag or lowercased name.
+func (d DotSql) QueryNamed(db Queryer, name string, arg any) (*sql.Rows, error) {
+	if err := d.checkCommand(name, "QueryNamed"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupNamed(name, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return rows, nil
+}
+
+// QueryNamedContext is like QueryContext, with parameters bound as in
+// QueryNamed.
+func (d DotSql) QueryNamedContext(ctx context.Context, db QueryerContext, name string, arg any) (*sql.Rows, error) {
+	if err := d.checkCommand(name, "QueryNamedContext"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupNamed(name, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return rows, nil
+}
+
+// QueryRowNamed is like QueryRow, with parameters bound as in QueryNamed.
+func (d DotSql) QueryRowNamed(db QueryRower, name string, arg any) (*sql.Row, error) {
+	if err := d.checkCommand(name, "QueryRowNamed"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupNamed(name, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.QueryRow(query, args...), nil
+}
+
+// QueryRowNamedContext is like QueryRowContext, with parameters bound as in
+// QueryNamed.
+func (d DotSql) QueryRowNamedContext(ctx context.Context, db QueryRowerContext, name string, arg any) (*sql.Row, error) {
+	if err := d.checkCommand(name, "QueryRowNamedContext"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupNamed(name, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.QueryRowContext(ctx, query, args...), nil
+}
+
+// ExecNamed is like Exec, with parameters bound as in QueryNamed.
+func (d DotSql) ExecNamed(db Execer, name string, arg any) (sql.Result, error) {
+	if err := d.checkCommand(name, "ExecNamed"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupNamed(name, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := db.Exec(query, args...)
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return res, nil
+}
+
+// ExecNamedContext is like ExecContext, with parameters bound as in
+// QueryNamed.
+func (d DotSql) ExecNamedContext(ctx context.Context, db ExecerContext, name string, arg any) (sql.Result, error) {
+	if err := d.checkCommand(name, "ExecNamedContext"); err != nil {
+		return nil, err
+	}
+
+	query, args, err := d.lookupNamed(name, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return res, nil
+}
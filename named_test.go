@@ -0,0 +1,115 @@
+package dotsql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+type namedBase struct {
+	ID int64 `db:"id"`
+}
+
+type namedUser struct {
+	namedBase
+	Name    string
+	Email   string `db:"email_address"`
+	Ignored string `db:"-"`
+	secret  string
+}
+
+func TestBindNamed(t *testing.T) {
+	user := namedUser{namedBase: namedBase{ID: 7}, Name: "foo", Email: "foo@bar.com", secret: "s"}
+
+	var tests = []struct {
+		query string
+		arg   any
+		want  string
+		args  []any
+	}{
+		{
+			"SELECT * FROM users WHERE email = :email AND name = @name",
+			map[string]any{"email": "foo@bar.com", "name": "foo"},
+			"SELECT * FROM users WHERE email = ? AND name = ?",
+			[]any{"foo@bar.com", "foo"},
+		},
+		{
+			"UPDATE users SET name = :name, email = :email_address WHERE id = :id AND id = :id",
+			user,
+			"UPDATE users SET name = ?, email = ? WHERE id = ? AND id = ?",
+			[]any{"foo", "foo@bar.com", int64(7), int64(7)},
+		},
+		{
+			"SELECT id::text, @@version, ':name', \"@name\" /* :name */ FROM users WHERE name = :name -- :name",
+			&user,
+			"SELECT id::text, @@version, ':name', \"@name\" /* :name */ FROM users WHERE name = ? -- :name",
+			[]any{"foo"},
+		},
+		{
+			"SELECT 1",
+			map[string]string{},
+			"SELECT 1",
+			nil,
+		},
+	}
+
+	for _, c := range tests {
+		got, args, err := bindNamed(c.query, c.arg, false)
+		failIfError(t, err)
+		if got != c.want {
+			t.Errorf("bindNamed(%q) == %q, expected %q", c.query, got, c.want)
+		}
+		if !reflect.DeepEqual(args, c.args) {
+			t.Errorf("bindNamed(%q) args == %v, expected %v", c.query, args, c.args)
+		}
+	}
+
+	var failures = []struct {
+		query string
+		arg   any
+	}{
+		{"SELECT :missing", map[string]any{}},
+		{"SELECT :secret", user},
+		{"SELECT :ignored", user},
+		{"SELECT :name", []string{"foo"}},
+		{"SELECT :name", nil},
+	}
+
+	for _, c := range failures {
+		_, _, err := bindNamed(c.query, c.arg, false)
+		if err == nil {
+			t.Errorf("bindNamed(%q, %v) expected to fail", c.query, c.arg)
+		}
+	}
+}
+
+func TestExecNamed(t *testing.T) {
+	dot, err := LoadFromString("-- name: create-user\nINSERT INTO users (name, email) VALUES (:name, :email_address)")
+	failIfError(t, err)
+
+	execer := &ExecerContextMock{
+		ExecContextFunc: func(_ context.Context, _ string, _ ...interface{}) (sql.Result, error) {
+			return sqlResult{}, nil
+		},
+	}
+
+	ctx := context.Background()
+	_, err = dot.ExecNamedContext(ctx, execer, "create-user", namedUser{Name: "foo", Email: "foo@bar.com"})
+	failIfError(t, err)
+
+	calls := execer.ExecContextCalls()
+	expectedQuery := "INSERT INTO users (name, email) VALUES (?, ?)"
+	if len(calls) != 1 || calls[0].Query != expectedQuery {
+		t.Fatalf("exec was expected to be called once with %q, got %+v", expectedQuery, calls)
+	}
+	if !reflect.DeepEqual(calls[0].Args, []any{"foo", "foo@bar.com"}) {
+		t.Errorf("exec was expected to be called with %v, got %v", []any{"foo", "foo@bar.com"}, calls[0].Args)
+	}
+
+	_, err = dot.ExecNamedContext(ctx, execer, "create-user", map[string]any{"name": "foo"})
+	expectedErr := `dotsql: "create-user" (line 1-2): missing value for named parameter "email_address"`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+	}
+}
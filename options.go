@@ -0,0 +1,85 @@
+package dotsql
+
+// Option configures how queries are loaded.
+type Option func(*config)
+
+type config struct {
+	separator  string
+	duplicates DuplicatePolicy
+	preserve   bool
+	tags       TagParser
+	bind       BindStyle
+	escapes    bool
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		separator: ".",
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithSeparator sets the separator LoadFromDir puts between the directories
+// of a query's namespace and its name. It defaults to ".".
+func WithSeparator(sep string) Option {
+	return func(c *config) {
+		c.separator = sep
+	}
+}
+
+// WithDuplicates sets how queries defined more than once are handled, both
+// within a file and across the files loaded by LoadFromDir. The default is
+// DuplicateLastWins.
+func WithDuplicates(policy DuplicatePolicy) Option {
+	return func(c *config) {
+		c.duplicates = policy
+	}
+}
+
+// Strict rejects inputs defining a query name more than once, returning a
+// *DuplicateError naming both definitions. It is a shorthand for
+// WithDuplicates(DuplicateReject).
+func Strict() Option {
+	return WithDuplicates(DuplicateReject)
+}
+
+// PreserveFormatting keeps query bodies byte for byte as written, from the
+// line after the name tag up to the next tag, so that Raw returns exactly
+// what is in the file. By default every line is trimmed and blank lines are
+// dropped.
+func PreserveFormatting() Option {
+	return func(c *config) {
+		c.preserve = true
+	}
+}
+
+// WithTagParser sets the parser recognizing name tags, e.g. YesqlTags or
+// HugSQLTags to load files written for those libraries. It defaults to
+// DefaultTags.
+func WithTagParser(p TagParser) Option {
+	return func(c *config) {
+		c.tags = p
+	}
+}
+
+// WithBindStyle sets the placeholder syntax the ? placeholders of the loaded
+// queries are rewritten to. See also DotSql.WithBindStyle.
+func WithBindStyle(style BindStyle) Option {
+	return func(c *config) {
+		c.bind = style
+	}
+}
+
+// BackslashEscapes makes backslashes escape the next character in quoted
+// strings, as MySQL does unless NO_BACKSLASH_ESCAPES is set, so that 'it\'s'
+// is read as a single string. It also makes # start a comment, as in MySQL.
+// Without it, backslashes are only escapes in PostgreSQL E'...' strings, and
+// # is an operator.
+func BackslashEscapes() Option {
+	return func(c *config) {
+		c.escapes = true
+	}
+}
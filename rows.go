@@ -0,0 +1,275 @@
+package dotsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"reflect"
+	"time"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// Select runs the named query with QueryContext and scans every row into a T.
+//
+// If T is a struct, columns are matched to its fields by their "db" tag or
+// lowercased name, and every column must have a matching field. Otherwise
+// the query must return a single column, scanned into T.
+func Select[T any](ctx context.Context, dot *DotSql, db QueryerContext, name string, args ...any) ([]T, error) {
+	rows, err := dot.QueryContext(ctx, db, name, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scan, err := rowScanner[T](rows)
+	if err != nil {
+		return nil, dot.queryError(name, err)
+	}
+
+	var result []T
+	for rows.Next() {
+		var v T
+		if err := scan(&v); err != nil {
+			return nil, dot.queryError(name, err)
+		}
+		result = append(result, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, dot.queryError(name, err)
+	}
+
+	return result, nil
+}
+
+// Get runs the named query with QueryContext and scans its first row into a
+// T, as in Select. It returns an error wrapping sql.ErrNoRows if the query
+// returns no rows.
+func Get[T any](ctx context.Context, dot *DotSql, db QueryerContext, name string, args ...any) (T, error) {
+	var v T
+	rows, err := dot.QueryContext(ctx, db, name, args...)
+	if err != nil {
+		return v, err
+	}
+	defer rows.Close()
+
+	scan, err := rowScanner[T](rows)
+	if err != nil {
+		return v, dot.queryError(name, err)
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return v, dot.queryError(name, err)
+		}
+		return v, dot.queryError(name, sql.ErrNoRows)
+	}
+	if err := scan(&v); err != nil {
+		return v, dot.queryError(name, err)
+	}
+
+	return v, nil
+}
+
+// Rows runs the named query with QueryContext and returns an iterator over
+// its rows, scanned into a T as in Select. The rows are closed when the
+// iteration ends, including when the loop is exited early. Errors, either
+// running the query or scanning a row, are yielded once and end the
+// iteration.
+//
+//	for user, err := range dotsql.Rows[User](ctx, dot, db, "find-users") {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Rows[T any](ctx context.Context, dot *DotSql, db QueryerContext, name string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		rows, err := dot.QueryContext(ctx, db, name, args...)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		scan, err := rowScanner[T](rows)
+		if err != nil {
+			yield(zero, dot.queryError(name, err))
+			return
+		}
+
+		for rows.Next() {
+			var v T
+			if err := scan(&v); err != nil {
+				yield(zero, dot.queryError(name, err))
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(zero, dot.queryError(name, err))
+		}
+	}
+}
+
+// rowScanner returns a function scanning the current row of rows into a T.
+func rowScanner[T any](rows *sql.Rows) (func(*T) error, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if !isStruct(t) {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("cannot scan %d columns into %s", len(columns), t)
+		}
+		return func(v *T) error {
+			return rows.Scan(v)
+		}, nil
+	}
+
+	fields := fieldsByName(t)
+	indexes := make([][]int, len(columns))
+	for i, column := range columns {
+		index, ok := fields[column]
+		if !ok {
+			return nil, fmt.Errorf("missing destination for column %q in %s", column, t)
+		}
+		indexes[i] = index
+	}
+
+	return func(v *T) error {
+		dest := make([]any, len(indexes))
+		rv := reflect.ValueOf(v).Elem()
+		for i, index := range indexes {
+			dest[i] = fieldForScan(rv, index).Addr().Interface()
+		}
+		return rows.Scan(dest...)
+	}, nil
+}
+
+// isStruct reports whether rows are scanned into the fields of t, rather
+// than into t itself.
+func isStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PointerTo(t).Implements(scannerType)
+}
+
+// fieldForScan is like reflect.Value.FieldByIndex, but allocates nil
+// embedded pointers on the way.
+func fieldForScan(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// Scalar runs the named query with QueryContext and scans the single column
+// of its first row into a T, as needed by count queries. It returns an error
+// if the query does not return exactly one column, or an error wrapping
+// sql.ErrNoRows if it returns no rows.
+func Scalar[T any](ctx context.Context, dot *DotSql, db QueryerContext, name string, args ...any) (T, error) {
+	var v T
+	rows, err := dot.QueryContext(ctx, db, name, args...)
+	if err != nil {
+		return v, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return v, dot.queryError(name, err)
+	}
+	if len(columns) != 1 {
+		return v, dot.queryError(name, fmt.Errorf("query returns %d columns, expected 1", len(columns)))
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return v, dot.queryError(name, err)
+		}
+		return v, dot.queryError(name, sql.ErrNoRows)
+	}
+	if err := rows.Scan(&v); err != nil {
+		return v, dot.queryError(name, err)
+	}
+
+	return v, nil
+}
+
+// Exists runs the named query with QueryContext and reports whether it
+// returns at least one row.
+func Exists(ctx context.Context, dot *DotSql, db QueryerContext, name string, args ...any) (bool, error) {
+	rows, err := dot.QueryContext(ctx, db, name, args...)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		return true, nil
+	}
+	if err := rows.Err(); err != nil {
+		return false, dot.queryError(name, err)
+	}
+
+	return false, nil
+}
+
+// QueryMaps runs the named query with QueryContext and returns every row as a
+// map from column name to value. Values are of the types returned by the
+// driver, except for []byte, which is converted to string. It is meant for
+// queries whose columns are not known in advance; prefer Select otherwise.
+func (d DotSql) QueryMaps(ctx context.Context, db QueryerContext, name string, args ...any) ([]map[string]any, error) {
+	rows, err := d.QueryContext(ctx, db, name, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+
+	var result []map[string]any
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, d.queryError(name, err)
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[column] = string(b)
+			} else {
+				row[column] = values[i]
+			}
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, d.queryError(name, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,203 @@
+package dotsql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type rowUser struct {
+	ID    int64
+	Name  string
+	Email sql.NullString `db:"email_address"`
+}
+
+func TestSelectRows(t *testing.T) {
+	dot, err := LoadFromString("-- name: find-users\nSELECT id, name, email_address FROM users\n\n-- name: find-names\nSELECT name FROM users")
+	failIfError(t, err)
+
+	ctx := context.Background()
+	db := fakeDB(t, []string{"id", "name", "email_address"},
+		[]driver.Value{int64(1), "foo", "foo@bar.com"},
+		[]driver.Value{int64(2), "bar", nil},
+	)
+
+	users, err := Select[rowUser](ctx, dot, db, "find-users")
+	failIfError(t, err)
+	expected := []rowUser{
+		{ID: 1, Name: "foo", Email: sql.NullString{String: "foo@bar.com", Valid: true}},
+		{ID: 2, Name: "bar"},
+	}
+	if !reflect.DeepEqual(users, expected) {
+		t.Errorf("Select() == %+v, expected %+v", users, expected)
+	}
+
+	_, err = Select[struct{ ID int64 }](ctx, dot, db, "find-users")
+	expectedErr := `dotsql: "find-users" (line 1-2): missing destination for column "name" in struct { ID int64 }`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+	}
+
+	_, err = Select[string](ctx, dot, db, "find-users")
+	failIfNotError(t, err)
+
+	names, err := Select[string](ctx, dot, fakeDB(t, []string{"name"}, []driver.Value{"foo"}), "find-names")
+	failIfError(t, err)
+	if !reflect.DeepEqual(names, []string{"foo"}) {
+		t.Errorf("Select() == %v, expected %v", names, []string{"foo"})
+	}
+
+	_, err = Select[rowUser](ctx, dot, db, "non-existent")
+	failIfNotError(t, err)
+}
+
+func TestGet(t *testing.T) {
+	dot, err := LoadFromString("-- name: find-user\nSELECT id, name, email_address FROM users WHERE id = ?")
+	failIfError(t, err)
+
+	ctx := context.Background()
+	db := fakeDB(t, []string{"id", "name"},
+		[]driver.Value{int64(1), "foo"},
+		[]driver.Value{int64(2), "bar"},
+	)
+
+	user, err := Get[rowUser](ctx, dot, db, "find-user", 1)
+	failIfError(t, err)
+	if expected := (rowUser{ID: 1, Name: "foo"}); user != expected {
+		t.Errorf("Get() == %+v, expected %+v", user, expected)
+	}
+
+	_, err = Get[rowUser](ctx, dot, fakeDB(t, []string{"id"}), "find-user", 1)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got '%v'", err)
+	}
+
+	_, err = Get[rowUser](ctx, dot, db, "find-user")
+	failIfNotError(t, err)
+}
+
+func TestScalar(t *testing.T) {
+	dot, err := LoadFromString("-- name: count-users\nSELECT count(*) FROM users")
+	failIfError(t, err)
+
+	ctx := context.Background()
+	count, err := Scalar[int](ctx, dot, fakeDB(t, []string{"count"}, []driver.Value{int64(42)}), "count-users")
+	failIfError(t, err)
+	if count != 42 {
+		t.Errorf("Scalar() == %d, expected %d", count, 42)
+	}
+
+	_, err = Scalar[int](ctx, dot, fakeDB(t, []string{"count"}), "count-users")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows, got '%v'", err)
+	}
+
+	_, err = Scalar[int](ctx, dot, fakeDB(t, []string{"a", "b"}, []driver.Value{int64(1), int64(2)}), "count-users")
+	expectedErr := `dotsql: "count-users" (line 1-2): query returns 2 columns, expected 1`
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+	}
+
+	_, err = Scalar[int](ctx, dot, fakeDB(t, []string{"count"}, []driver.Value{"many"}), "count-users")
+	failIfNotError(t, err)
+}
+
+func TestExists(t *testing.T) {
+	dot, err := LoadFromString("-- name: find-user\nSELECT 1 FROM users WHERE email = ?")
+	failIfError(t, err)
+
+	ctx := context.Background()
+	exists, err := Exists(ctx, dot, fakeDB(t, []string{"1"}, []driver.Value{int64(1)}), "find-user", "foo@bar.com")
+	failIfError(t, err)
+	if !exists {
+		t.Error("Exists() == false, expected true")
+	}
+
+	exists, err = Exists(ctx, dot, fakeDB(t, []string{"1"}), "find-user", "foo@bar.com")
+	failIfError(t, err)
+	if exists {
+		t.Error("Exists() == true, expected false")
+	}
+
+	_, err = Exists(ctx, dot, fakeDB(t, []string{"1"}), "find-user")
+	failIfNotError(t, err)
+}
+
+func TestRows(t *testing.T) {
+	dot, err := LoadFromString("-- name: find-users\nSELECT id, name FROM users")
+	failIfError(t, err)
+
+	ctx := context.Background()
+	db := fakeDB(t, []string{"id", "name"},
+		[]driver.Value{int64(1), "foo"},
+		[]driver.Value{int64(2), "bar"},
+		[]driver.Value{int64(3), "baz"},
+	)
+
+	var users []rowUser
+	for user, err := range Rows[rowUser](ctx, dot, db, "find-users") {
+		failIfError(t, err)
+		users = append(users, user)
+	}
+	if len(users) != 3 || users[2].Name != "baz" {
+		t.Errorf("Rows() yielded %+v", users)
+	}
+
+	users = nil
+	for user, err := range Rows[rowUser](ctx, dot, db, "find-users") {
+		failIfError(t, err)
+		users = append(users, user)
+		break
+	}
+	if len(users) != 1 {
+		t.Errorf("Rows() yielded %d users after break, expected 1", len(users))
+	}
+	if stats := db.Stats(); stats.InUse != 0 {
+		t.Errorf("%d connections still in use, expected rows to be closed", stats.InUse)
+	}
+
+	var errs int
+	for _, err := range Rows[rowUser](ctx, dot, db, "non-existent") {
+		failIfNotError(t, err)
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("Rows() yielded %d errors, expected 1", errs)
+	}
+
+	errs = 0
+	for _, err := range Rows[int](ctx, dot, db, "find-users") {
+		failIfNotError(t, err)
+		errs++
+	}
+	if errs != 1 {
+		t.Errorf("Rows() yielded %d errors, expected 1", errs)
+	}
+}
+
+func TestQueryMaps(t *testing.T) {
+	dot, err := LoadFromString("-- name: find-users\nSELECT id, name, email FROM users")
+	failIfError(t, err)
+
+	ctx := context.Background()
+	db := fakeDB(t, []string{"id", "name", "email"},
+		[]driver.Value{int64(1), []byte("foo"), "foo@bar.com"},
+		[]driver.Value{int64(2), []byte("bar"), nil},
+	)
+
+	rows, err := dot.QueryMaps(ctx, db, "find-users")
+	failIfError(t, err)
+	expected := []map[string]any{
+		{"id": int64(1), "name": "foo", "email": "foo@bar.com"},
+		{"id": int64(2), "name": "bar", "email": nil},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("QueryMaps() == %+v, expected %+v", rows, expected)
+	}
+
+	_, err = dot.QueryMaps(ctx, db, "non-existent")
+	failIfNotError(t, err)
+}
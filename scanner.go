@@ -2,66 +2,406 @@ package dotsql
 
 import (
 	"bufio"
+	"bytes"
+	"fmt"
 	"regexp"
 	"strings"
 )
 
+// Position describes where a query is defined.
+type Position struct {
+	File      string // file name, empty if the query was not loaded from a file
+	StartLine int    // line of the name tag, starting at 1
+	EndLine   int    // last line of the query body
+}
+
+// String returns the position in the form "file:start-end". The file is
+// omitted when unknown, and so is the end line when it equals the start line.
+func (p Position) String() string {
+	lines := fmt.Sprintf("%d", p.StartLine)
+	if p.EndLine > p.StartLine {
+		lines = fmt.Sprintf("%d-%d", p.StartLine, p.EndLine)
+	}
+	if p.File == "" {
+		return "line " + lines
+	}
+	return p.File + ":" + lines
+}
+
+// DuplicatePolicy defines what happens when a query name is defined more
+// than once.
+type DuplicatePolicy int
+
+const (
+	// DuplicateLastWins keeps the last definition of a query. It is the
+	// default, and matches the behavior of Merge.
+	DuplicateLastWins DuplicatePolicy = iota
+	// DuplicateFirstWins keeps the first definition of a query and ignores
+	// the others.
+	DuplicateFirstWins
+	// DuplicateReject rejects the input with a *DuplicateError.
+	DuplicateReject
+)
+
+// DuplicateError reports a query name defined more than once.
+type DuplicateError struct {
+	Name   string
+	First  Position
+	Second Position
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("dotsql: query %q defined twice, at %s and %s", e.Name, e.First, e.Second)
+}
+
 type Scanner struct {
-	line    string
-	queries map[string]string
-	current string
+	// File is the file name recorded in the position of every query.
+	File string
+	// Duplicates defines how queries sharing a name are handled.
+	Duplicates DuplicatePolicy
+	// Tags recognizes name tags. It defaults to DefaultTags.
+	Tags TagParser
+	// Preserve keeps query bodies as written instead of trimming every line
+	// and dropping blank ones. Line terminators are kept if the
+	// bufio.Scanner split function returns them, as ScanLines does.
+	Preserve bool
+	// BackslashEscapes reads queries as MySQL does: backslashes escape the
+	// next character in quoted strings, and # starts a comment. Backslashes
+	// are always escapes in PostgreSQL E'...' strings.
+	BackslashEscapes bool
+	// Warn, if set, is called for every construct that is likely a mistake:
+	// text before the first name tag, which is ignored, text after a name
+	// tag, queries with an empty body, which are dropped, queries defined
+	// more than once, and template data interpolated where SQL expects a
+	// value. It is also called for the problems making Run fail, other than
+	// read errors, so that all of them are reported and not just the first:
+	// unknown commands, and literals or comments left unterminated at the
+	// end of the input.
+	Warn func(pos Position, msg string)
+
+	line         string
+	raw          string
+	lineNo       int
+	queries      map[string]string
+	descriptors  map[string]Query
+	current      Query
+	body         string
+	unterminated bool
+	annotating   bool
+	pending      []sourceLine
+	lex          lexer
+	preamble     bool
+	err          error
 }
 
 type stateFn func(*Scanner) stateFn
 
-func getTag(line string) string {
-	re := regexp.MustCompile("^\\s*--\\s*name:\\s*(\\S+)")
-	matches := re.FindStringSubmatch(line)
+var (
+	tagRegexp        = regexp.MustCompile("^\\s*--\\s*name:\\s*(\\S+)(?:\\s+(:\\S+))?\\s*(.*?)\\s*$")
+	annotationRegexp = regexp.MustCompile("^\\s*--\\s*([a-z][a-z0-9_.-]*)\\s*:\\s*(.*?)\\s*$")
+)
+
+// getTag returns the query name, its optional command and any text following
+// them if line is a name tag.
+func getTag(line string) (string, Command, string) {
+	matches := tagRegexp.FindStringSubmatch(line)
 	if matches == nil {
-		return ""
+		return "", CommandNone, ""
 	}
-	return matches[1]
+	return matches[1], Command(matches[2]), matches[3]
+}
+
+// sourceLine is a line of input, with and without its terminator.
+type sourceLine struct {
+	text   string
+	raw    string
+	number int
 }
 
 func initialState(s *Scanner) stateFn {
-	if tag := getTag(s.line); len(tag) > 0 {
-		s.current = tag
+	if tag, ok := s.parseTag(); ok {
+		s.setCurrent(tag)
 		return queryState
 	}
+	if isComment(s.line) {
+		s.pending = append(s.pending, s.sourceLine())
+	} else {
+		s.pending = s.pending[:0]
+	}
+	if !s.preamble && !isComment(s.line) && !isBlank(s.line) {
+		s.preamble = true
+		s.warn(Position{File: s.File, StartLine: s.lineNo, EndLine: s.lineNo}, "text before the first name tag is ignored")
+	}
 	return initialState
 }
 
 func queryState(s *Scanner) stateFn {
-	if tag := getTag(s.line); len(tag) > 0 {
-		s.current = tag
-	} else {
-		s.appendQueryLine()
+	// Tags are only recognized at statement level, so that a string literal,
+	// block comment or dollar quoted body may contain lines looking like one.
+	if s.lex.inCode() {
+		if tag, ok := s.parseTag(); ok {
+			s.setCurrent(tag)
+			return queryState
+		}
+	}
+
+	if s.annotating {
+		if key, value := getAnnotation(s.line); len(key) > 0 {
+			s.current.Metadata[key] = value
+			return queryState
+		}
+		s.annotating = false
 	}
+
+	// Comments and blank lines are held back until the next statement line,
+	// as they may turn out to document the next query.
+	if s.lex.inCode() && (isComment(s.line) || isBlank(s.line)) {
+		s.pending = append(s.pending, s.sourceLine())
+		return queryState
+	}
+
+	s.flushPending()
+	s.appendQueryLine(s.sourceLine())
+	s.lex.feed(s.line)
 	return queryState
 }
 
-func (s *Scanner) appendQueryLine() {
-	current := s.queries[s.current]
-	line := strings.Trim(s.line, " \t")
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " \t"), "--")
+}
+
+func isBlank(line string) bool {
+	return len(strings.Trim(line, " \t")) == 0
+}
+
+func (s *Scanner) sourceLine() sourceLine {
+	return sourceLine{text: s.line, raw: s.raw, number: s.lineNo}
+}
+
+// flushPending appends the held back lines to the current query.
+func (s *Scanner) flushPending() {
+	for _, line := range s.pending {
+		s.appendQueryLine(line)
+	}
+	s.pending = s.pending[:0]
+}
+
+// takeDescription removes the comment lines immediately preceding the
+// current line from the held back ones, and returns their text.
+func (s *Scanner) takeDescription() string {
+	i := len(s.pending)
+	for i > 0 && isComment(s.pending[i-1].text) {
+		i--
+	}
+
+	lines := make([]string, 0, len(s.pending)-i)
+	for _, line := range s.pending[i:] {
+		text := strings.TrimPrefix(strings.TrimLeft(line.text, " \t"), "--")
+		lines = append(lines, strings.TrimSpace(text))
+	}
+	s.pending = s.pending[:i]
+
+	return strings.Join(lines, "\n")
+}
+
+// getAnnotation returns the key and value if line is a "-- key: value"
+// annotation comment.
+func getAnnotation(line string) (string, string) {
+	matches := annotationRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return "", ""
+	}
+	return matches[1], matches[2]
+}
+
+func (s *Scanner) parseTag() (Tag, bool) {
+	if s.Tags == nil {
+		return DefaultTags(s.line)
+	}
+	return s.Tags(s.line)
+}
+
+func (s *Scanner) setCurrent(tag Tag) {
+	description := s.takeDescription()
+	if s.current.Name != "" {
+		s.flushPending()
+	}
+	s.pending = s.pending[:0]
+	s.storeCurrent()
+
+	s.current = Query{
+		Name:        tag.Name,
+		Command:     tag.Command,
+		Description: description,
+		Position:    Position{File: s.File, StartLine: s.lineNo, EndLine: s.lineNo},
+		Metadata:    make(map[string]string),
+	}
+	for key, value := range tag.Metadata {
+		s.current.Metadata[key] = value
+	}
+	s.body = ""
+	s.unterminated = false
+	s.annotating = true
+	s.lex = lexer{escapes: s.BackslashEscapes}
+
+	if !tag.Command.valid() {
+		s.warn(s.current.Position, fmt.Sprintf("query %q has an unknown command %q", tag.Name, tag.Command))
+		if s.err == nil {
+			s.err = fmt.Errorf("dotsql: %q (%s): unknown command %q", tag.Name, s.current.Position, tag.Command)
+		}
+	}
+	if tag.Trailing != "" {
+		s.warn(s.current.Position, fmt.Sprintf("unexpected %q after the name tag of %q", tag.Trailing, tag.Name))
+	}
+}
+
+// warn reports a likely mistake to the Warn function, if any.
+func (s *Scanner) warn(pos Position, msg string) {
+	if s.Warn != nil {
+		s.Warn(pos, msg)
+	}
+}
+
+func (s *Scanner) appendQueryLine(l sourceLine) {
+	if s.Preserve {
+		s.appendRawLine(l)
+		return
+	}
+
+	line := strings.Trim(l.text, " \t")
 	if len(line) == 0 {
 		return
 	}
 
-	if len(current) > 0 {
-		current = current + "\n"
+	if len(s.body) > 0 {
+		s.body = s.body + "\n"
+	}
+
+	s.body = s.body + line
+	s.current.Position.EndLine = l.number
+}
+
+func (s *Scanner) appendRawLine(l sourceLine) {
+	if s.unterminated {
+		// The bufio.Scanner strips line terminators.
+		s.body = s.body + "\n"
 	}
 
-	current = current + line
-	s.queries[s.current] = current
+	s.body = s.body + l.raw
+	s.unterminated = !strings.HasSuffix(l.raw, "\n")
+	if len(strings.TrimSpace(l.text)) > 0 {
+		s.current.Position.EndLine = l.number
+	}
+}
+
+// storeCurrent saves the query being scanned, if any, according to the
+// duplicate policy. Queries with an empty body are discarded.
+func (s *Scanner) storeCurrent() {
+	name := s.current.Name
+	if name == "" {
+		return
+	}
+	if len(strings.TrimSpace(s.body)) == 0 {
+		s.warn(s.current.Position, fmt.Sprintf("query %q has an empty body", name))
+		return
+	}
+	if s.Warn != nil && interpolatesValue(s.body, s.BackslashEscapes) {
+		s.warn(s.current.Position, fmt.Sprintf("query %q interpolates template data where a value is expected, use a placeholder instead", name))
+	}
+
+	if _, ok := s.queries[name]; ok {
+		s.warn(s.current.Position, fmt.Sprintf("query %q already defined at %s", name, s.descriptors[name].Position))
+		switch s.Duplicates {
+		case DuplicateFirstWins:
+			return
+		case DuplicateReject:
+			if s.err == nil {
+				s.err = &DuplicateError{
+					Name:   name,
+					First:  s.descriptors[name].Position,
+					Second: s.current.Position,
+				}
+			}
+			return
+		}
+	}
+
+	s.queries[name] = s.body
+	s.descriptors[name] = s.current
 }
 
 func (s *Scanner) Run(io *bufio.Scanner) map[string]string {
 	s.queries = make(map[string]string)
+	s.descriptors = make(map[string]Query)
+	s.current = Query{}
+	s.pending = nil
+	s.lineNo = 0
+	s.lex = lexer{escapes: s.BackslashEscapes}
+	s.preamble = false
+	s.err = nil
 
 	for state := initialState; io.Scan(); {
-		s.line = io.Text()
+		s.raw = io.Text()
+		s.line = strings.TrimSuffix(strings.TrimSuffix(s.raw, "\n"), "\r")
+		s.lineNo++
 		state = state(s)
 	}
+	s.flushPending()
+	if !s.lex.inCode() {
+		s.warn(s.current.Position, fmt.Sprintf("query %q has an unterminated %s", s.current.Name, s.lex.mode))
+		if s.err == nil {
+			s.err = fmt.Errorf("dotsql: %q (%s): unterminated %s", s.current.Name, s.current.Position, s.lex.mode)
+		}
+	}
+	s.storeCurrent()
+	if err := io.Err(); err != nil {
+		s.err = err
+	}
 
 	return s.queries
 }
+
+// Err returns the first error encountered during the last call to Run: a
+// non-EOF error of the bufio.Scanner, an unknown query command, a literal or
+// comment left unterminated at the end of the input, which likely swallowed
+// the queries following it, or, with DuplicateReject, a *DuplicateError.
+// Queries returned by a Run that failed may be incomplete.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Positions returns the position of every query found by the last call to
+// Run.
+func (s *Scanner) Positions() map[string]Position {
+	positions := make(map[string]Position, len(s.descriptors))
+	for name, query := range s.descriptors {
+		positions[name] = query.Position
+	}
+	return positions
+}
+
+// Queries returns the descriptor of every query found by the last call to
+// Run.
+func (s *Scanner) Queries() map[string]Query {
+	queries := make(map[string]Query, len(s.descriptors))
+	for name, query := range s.descriptors {
+		queries[name] = query
+	}
+	return queries
+}
+
+// ScanLines is a bufio.SplitFunc like bufio.ScanLines, except that it keeps
+// line terminators, letting a Scanner with Preserve set reproduce query
+// bodies byte for byte.
+func ScanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
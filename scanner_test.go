@@ -2,26 +2,34 @@ package dotsql
 
 import (
 	"bufio"
+	"reflect"
 	"strings"
 	"testing"
 )
 
 func TestGetTag(t *testing.T) {
 	var tests = []struct {
-		line string
-		want string
+		line     string
+		want     string
+		command  Command
+		trailing string
 	}{
-		{"SELECT 1+1", ""},
-		{"-- Some Comment", ""},
-		{"-- name:  ", ""},
-		{"-- name: find-users-by-name", "find-users-by-name"},
-		{"  --  name:  save-user ", "save-user"},
+		{"SELECT 1+1", "", CommandNone, ""},
+		{"-- Some Comment", "", CommandNone, ""},
+		{"-- name:  ", "", CommandNone, ""},
+		{"-- name: find-users-by-name", "find-users-by-name", CommandNone, ""},
+		{"  --  name:  save-user ", "save-user", CommandNone, ""},
+		{"-- name: GetUser :one", "GetUser", CommandOne, ""},
+		{"-- name: ListUsers   :many ", "ListUsers", CommandMany, ""},
+		{"-- name: DeleteUser :exec", "DeleteUser", CommandExec, ""},
+		{"-- name: GetUser one", "GetUser", CommandNone, "one"},
+		{"-- name: GetUser :one -- by id ", "GetUser", CommandOne, "-- by id"},
 	}
 
 	for _, c := range tests {
-		got := getTag(c.line)
-		if got != c.want {
-			t.Errorf("isTag('%s') == %s, expect %v", c.line, got, c.want)
+		got, command, trailing := getTag(c.line)
+		if got != c.want || command != c.command || trailing != c.trailing {
+			t.Errorf("isTag('%s') == (%s, %s, %q), expect (%v, %v, %q)", c.line, got, command, trailing, c.want, c.command, c.trailing)
 		}
 	}
 }
@@ -47,3 +55,357 @@ func TestScannerRun(t *testing.T) {
 			numberOfQueries, expectedQueries)
 	}
 }
+
+func TestScannerPositions(t *testing.T) {
+	sqlFile := `-- name: all-users
+SELECT *
+FROM users
+
+-- name: empty-query-should-not-be-stored
+
+-- name: save-user
+INSERT INTO users (?, ?, ?)
+`
+
+	scanner := &Scanner{File: "queries.sql"}
+	scanner.Run(bufio.NewScanner(strings.NewReader(sqlFile)))
+
+	expected := map[string]Position{
+		"all-users": {File: "queries.sql", StartLine: 1, EndLine: 3},
+		"save-user": {File: "queries.sql", StartLine: 7, EndLine: 8},
+	}
+	got := scanner.Positions()
+	if len(got) != len(expected) {
+		t.Errorf("Scanner/Positions() has %d positions instead of %d", len(got), len(expected))
+	}
+	for name, pos := range expected {
+		if got[name] != pos {
+			t.Errorf("Scanner/Positions()[%s] == %+v, expected %+v", name, got[name], pos)
+		}
+	}
+}
+
+func TestPositionString(t *testing.T) {
+	var tests = []struct {
+		pos  Position
+		want string
+	}{
+		{Position{File: "queries.sql", StartLine: 1, EndLine: 3}, "queries.sql:1-3"},
+		{Position{File: "queries.sql", StartLine: 4, EndLine: 4}, "queries.sql:4"},
+		{Position{StartLine: 2, EndLine: 5}, "line 2-5"},
+	}
+
+	for _, c := range tests {
+		if got := c.pos.String(); got != c.want {
+			t.Errorf("Position.String() == %q, expected %q", got, c.want)
+		}
+	}
+}
+
+func TestScannerErr(t *testing.T) {
+	lines := bufio.NewScanner(strings.NewReader("-- name: long\nSELECT '" + strings.Repeat("x", 128) + "'"))
+	lines.Buffer(nil, 64)
+
+	scanner := &Scanner{}
+	scanner.Run(lines)
+	if scanner.Err() != bufio.ErrTooLong {
+		t.Errorf("Scanner/Err() == %v, expected %v", scanner.Err(), bufio.ErrTooLong)
+	}
+
+	scanner.Run(bufio.NewScanner(strings.NewReader("-- name: short\nSELECT 1")))
+	failIfError(t, scanner.Err())
+}
+
+func TestScannerDuplicates(t *testing.T) {
+	sqlFile := `-- name: find-users
+SELECT * FROM users
+
+-- name: find-users
+SELECT * FROM users
+WHERE active
+`
+
+	var tests = []struct {
+		policy DuplicatePolicy
+		want   string
+	}{
+		{DuplicateLastWins, "SELECT * FROM users\nWHERE active"},
+		{DuplicateFirstWins, "SELECT * FROM users"},
+	}
+
+	for _, c := range tests {
+		scanner := &Scanner{Duplicates: c.policy}
+		queries := scanner.Run(bufio.NewScanner(strings.NewReader(sqlFile)))
+		failIfError(t, scanner.Err())
+		if got := queries["find-users"]; got != c.want {
+			t.Errorf("Scanner/Run() with policy %d == %q, expected %q", c.policy, got, c.want)
+		}
+	}
+
+	scanner := &Scanner{File: "users.sql", Duplicates: DuplicateReject}
+	scanner.Run(bufio.NewScanner(strings.NewReader(sqlFile)))
+	expectedErr := `dotsql: query "find-users" defined twice, at users.sql:1-2 and users.sql:4-6`
+	if err := scanner.Err(); err == nil || err.Error() != expectedErr {
+		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+	}
+}
+
+func TestScannerPreserve(t *testing.T) {
+	sqlFile := "-- name: create-function\r\n" +
+		"CREATE FUNCTION greet() RETURNS text AS $$\r\n" +
+		"\r\n" +
+		"    SELECT 'hello\r\n" +
+		"      world'\r\n" +
+		"$$ LANGUAGE sql;\r\n" +
+		"\r\n" +
+		"-- name: select\n" +
+		"  SELECT 1"
+
+	lines := bufio.NewScanner(strings.NewReader(sqlFile))
+	lines.Split(ScanLines)
+
+	scanner := &Scanner{Preserve: true}
+	queries := scanner.Run(lines)
+
+	expected := map[string]string{
+		"create-function": "CREATE FUNCTION greet() RETURNS text AS $$\r\n\r\n    SELECT 'hello\r\n      world'\r\n$$ LANGUAGE sql;\r\n\r\n",
+		"select":          "  SELECT 1",
+	}
+	for name, query := range expected {
+		if queries[name] != query {
+			t.Errorf("Scanner/Run()[%s] == %q, expected %q", name, queries[name], query)
+		}
+	}
+
+	expectedPos := Position{StartLine: 1, EndLine: 6}
+	if pos := scanner.Positions()["create-function"]; pos != expectedPos {
+		t.Errorf("Scanner/Positions()[create-function] == %+v, expected %+v", pos, expectedPos)
+	}
+
+	// Without ScanLines line terminators are lost and lines are joined with "\n".
+	scanner.Run(bufio.NewScanner(strings.NewReader("-- name: select\n  SELECT 1\n\n  FROM t")))
+	if got, expected := scanner.queries["select"], "  SELECT 1\n\n  FROM t"; got != expected {
+		t.Errorf("Scanner/Run()[select] == %q, expected %q", got, expected)
+	}
+}
+
+func TestScannerIgnoresTagsInsideLiterals(t *testing.T) {
+	sqlFile := `-- name: create-function
+CREATE FUNCTION f() RETURNS text AS $$
+-- name: not-a-tag
+SELECT 'x'
+$$ LANGUAGE sql;
+
+-- name: insert-note
+INSERT INTO notes (body) VALUES ('first line
+-- name: not-a-tag-either
+last line')
+
+-- name: commented
+/* documentation
+-- name: still-not-a-tag
+*/
+SELECT 1
+
+-- name: last
+SELECT "quoted -- name: identifier"
+`
+
+	scanner := &Scanner{}
+	queries := scanner.Run(bufio.NewScanner(strings.NewReader(sqlFile)))
+
+	expected := []string{"create-function", "insert-note", "commented", "last"}
+	if len(queries) != len(expected) {
+		t.Errorf("Scanner/Run() has %d queries instead of %d", len(queries), len(expected))
+	}
+	for _, name := range expected {
+		if _, ok := queries[name]; !ok {
+			t.Errorf("Scanner/Run() expected to find %q", name)
+		}
+	}
+
+	expectedQuery := "INSERT INTO notes (body) VALUES ('first line\n-- name: not-a-tag-either\nlast line')"
+	if got := queries["insert-note"]; got != expectedQuery {
+		t.Errorf("Scanner/Run()[insert-note] == %q, expected %q", got, expectedQuery)
+	}
+}
+
+func TestScannerUnterminated(t *testing.T) {
+	sqlFile := "-- name: q1\nINSERT INTO t VALUES ('it\\'s')\n\n-- name: q2\nSELECT 2\n\n-- name: q3\nSELECT 3\n"
+
+	scanner := &Scanner{}
+	scanner.Run(bufio.NewScanner(strings.NewReader(sqlFile)))
+	expectedErr := `dotsql: "q1" (line 1-8): unterminated string literal`
+	if err := scanner.Err(); err == nil || err.Error() != expectedErr {
+		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+	}
+
+	scanner.BackslashEscapes = true
+	queries := scanner.Run(bufio.NewScanner(strings.NewReader(sqlFile)))
+	failIfError(t, scanner.Err())
+	if len(queries) != 3 {
+		t.Errorf("Scanner/Run() with BackslashEscapes has %d queries instead of 3", len(queries))
+	}
+}
+
+func TestScannerEscapeStrings(t *testing.T) {
+	sqlFile := "-- name: q1\nINSERT INTO t VALUES (E'it\\'s', E'\\\\')\n\n-- name: q2\nSELECT 'C:\\'\n\n-- name: q3\nSELECT 3\n"
+
+	scanner := &Scanner{}
+	queries := scanner.Run(bufio.NewScanner(strings.NewReader(sqlFile)))
+	failIfError(t, scanner.Err())
+	if len(queries) != 3 {
+		t.Errorf("Scanner/Run() has %d queries instead of 3", len(queries))
+	}
+}
+
+func TestGetAnnotation(t *testing.T) {
+	var tests = []struct {
+		line  string
+		key   string
+		value string
+	}{
+		{"SELECT 1+1", "", ""},
+		{"-- Some Comment", "", ""},
+		{"-- timeout: 2s", "timeout", "2s"},
+		{"  --readonly:true  ", "readonly", "true"},
+		{"-- tags: reporting,slow", "tags", "reporting,slow"},
+		{"-- empty:", "empty", ""},
+		{"-- TODO: fix", "", ""},
+		{"-- Note: keep", "", ""},
+	}
+
+	for _, c := range tests {
+		key, value := getAnnotation(c.line)
+		if key != c.key || value != c.value {
+			t.Errorf("getAnnotation('%s') == (%s, %s), expect (%s, %s)", c.line, key, value, c.key, c.value)
+		}
+	}
+}
+
+func TestScannerAnnotations(t *testing.T) {
+	sqlFile := `-- name: users-report
+-- timeout: 2s
+-- readonly: true
+-- tags: reporting,slow
+-- TODO: paginate
+SELECT * FROM users
+-- note: not an annotation
+
+-- name: plain
+-- Finds all users
+-- timeout: 1s
+SELECT * FROM users
+`
+
+	scanner := &Scanner{}
+	queries := scanner.Run(bufio.NewScanner(strings.NewReader(sqlFile)))
+
+	expectedQuery := "-- TODO: paginate\nSELECT * FROM users\n-- note: not an annotation"
+	if got := queries["users-report"]; got != expectedQuery {
+		t.Errorf("Scanner/Run()[users-report] == %q, expected %q", got, expectedQuery)
+	}
+
+	expectedMetadata := map[string]string{"timeout": "2s", "readonly": "true", "tags": "reporting,slow"}
+	if got := scanner.Queries()["users-report"].Metadata; !reflect.DeepEqual(got, expectedMetadata) {
+		t.Errorf("Scanner/Queries()[users-report].Metadata == %v, expected %v", got, expectedMetadata)
+	}
+
+	if got := scanner.Queries()["plain"].Metadata; len(got) != 0 {
+		t.Errorf("Scanner/Queries()[plain].Metadata == %v, expected no metadata", got)
+	}
+}
+
+func TestScannerDescriptions(t *testing.T) {
+	sqlFile := `-- Queries on users.
+
+-- Finds all users.
+-- Deleted users are included.
+-- name: all-users
+SELECT * FROM users
+-- trailing comment
+
+-- Saves a user.
+-- name: save-user
+-- timeout: 1s
+INSERT INTO users (?, ?, ?)
+
+-- name: undocumented
+SELECT 1
+`
+
+	scanner := &Scanner{}
+	queries := scanner.Run(bufio.NewScanner(strings.NewReader(sqlFile)))
+
+	expected := map[string]struct{ body, description string }{
+		"all-users":    {"SELECT * FROM users\n-- trailing comment", "Finds all users.\nDeleted users are included."},
+		"save-user":    {"INSERT INTO users (?, ?, ?)", "Saves a user."},
+		"undocumented": {"SELECT 1", ""},
+	}
+	descriptors := scanner.Queries()
+	for name, query := range expected {
+		if got := queries[name]; got != query.body {
+			t.Errorf("Scanner/Run()[%s] == %q, expected %q", name, got, query.body)
+		}
+		if got := descriptors[name].Description; got != query.description {
+			t.Errorf("Scanner/Queries()[%s].Description == %q, expected %q", name, got, query.description)
+		}
+	}
+
+	expectedPos := Position{StartLine: 5, EndLine: 7}
+	if pos := descriptors["all-users"].Position; pos != expectedPos {
+		t.Errorf("Scanner/Queries()[all-users].Position == %+v, expected %+v", pos, expectedPos)
+	}
+}
+
+func TestScannerUnknownCommand(t *testing.T) {
+	scanner := &Scanner{}
+	scanner.Run(bufio.NewScanner(strings.NewReader("-- name: GetUser :oen\nSELECT 1")))
+	expectedErr := `dotsql: "GetUser" (line 1): unknown command ":oen"`
+	if err := scanner.Err(); err == nil || err.Error() != expectedErr {
+		t.Errorf("expected '%s' error, but got '%v'", expectedErr, err)
+	}
+}
+
+func TestScannerWarn(t *testing.T) {
+	sqlFile := `SELECT 1;
+SELECT 2;
+
+-- name: find-users one
+SELECT * FROM users
+
+-- name: empty
+
+-- name: find-users
+SELECT * FROM users WHERE name = '{{.name}}'
+
+-- name: find-user
+SELECT * FROM users WHERE id = {{ .id }}
+
+-- name: find-active-users
+SELECT * FROM users {{if .active}}WHERE active{{end}}
+
+-- name: unterminated
+SELECT * FROM users WHERE name = 'foo
+`
+
+	var warnings []string
+	scanner := &Scanner{File: "users.sql", Warn: func(pos Position, msg string) {
+		warnings = append(warnings, pos.String()+": "+msg)
+	}}
+	scanner.Run(bufio.NewScanner(strings.NewReader(sqlFile)))
+	failIfNotError(t, scanner.Err())
+
+	expected := []string{
+		`users.sql:1: text before the first name tag is ignored`,
+		`users.sql:4: unexpected "one" after the name tag of "find-users"`,
+		`users.sql:7: query "empty" has an empty body`,
+		`users.sql:9-10: query "find-users" interpolates template data where a value is expected, use a placeholder instead`,
+		`users.sql:9-10: query "find-users" already defined at users.sql:4-5`,
+		`users.sql:12-13: query "find-user" interpolates template data where a value is expected, use a placeholder instead`,
+		`users.sql:18-19: query "unterminated" has an unterminated string literal`,
+	}
+	if !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("Scanner.Warn called with\n%s\nexpected\n%s", strings.Join(warnings, "\n"), strings.Join(expected, "\n"))
+	}
+}
@@ -0,0 +1,123 @@
+package dotsql
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Tag is a parsed name tag.
+type Tag struct {
+	Name    string
+	Command Command
+	// Metadata holds annotations given in the tag itself. They are merged
+	// with those written below it.
+	Metadata map[string]string
+	// Trailing is the text following the tag that the parser ignored, such
+	// as "one" in "-- name: GetUser one".
+	Trailing string
+}
+
+// TagParser recognizes name tags. It returns false if line is not a tag.
+type TagParser func(line string) (Tag, bool)
+
+var blockTagRegexp = regexp.MustCompile(`^\s*/\*\s*name:\s*(.*?)\s*\*/\s*$`)
+
+// DefaultTags parses dotsql tags: "-- name: find-users", optionally followed
+// by a sqlc command as in "-- name: GetUser :one". Tags may also be written
+// as a block comment taking the whole line, in which case annotations can
+// follow the name: "/* name: GetUser :one, timeout: 1s */".
+func DefaultTags(line string) (Tag, bool) {
+	if name, command, trailing := getTag(line); len(name) > 0 {
+		return Tag{Name: name, Command: command, Trailing: trailing}, true
+	}
+
+	matches := blockTagRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return Tag{}, false
+	}
+
+	fields := strings.Split(matches[1], ",")
+	name, command, _ := strings.Cut(strings.TrimSpace(fields[0]), " ")
+	if len(name) == 0 {
+		return Tag{}, false
+	}
+
+	tag := Tag{Name: name, Command: Command(strings.TrimSpace(command))}
+	for _, field := range fields[1:] {
+		key, value, _ := strings.Cut(field, ":")
+		if key = strings.TrimSpace(key); len(key) > 0 {
+			if tag.Metadata == nil {
+				tag.Metadata = make(map[string]string)
+			}
+			tag.Metadata[key] = strings.TrimSpace(value)
+		}
+	}
+	return tag, true
+}
+
+var yesqlTagRegexp = regexp.MustCompile(`^\s*--\s*name:\s*([^\s!<]+)(<?!)?\s*$`)
+
+// YesqlTags parses yesql tags: "-- name: find-users". Names ending in "!"
+// declare statements not returning rows (CommandExec), and names ending in
+// "<!" inserts returning the generated keys (CommandExecResult). The suffix
+// is not part of the query name.
+func YesqlTags(line string) (Tag, bool) {
+	matches := yesqlTagRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return Tag{}, false
+	}
+
+	tag := Tag{Name: matches[1]}
+	switch matches[2] {
+	case "!":
+		tag.Command = CommandExec
+	case "<!":
+		tag.Command = CommandExecResult
+	}
+	return tag, true
+}
+
+var hugSQLTagRegexp = regexp.MustCompile(`^\s*--\s*:name\s+(\S+)((?:\s+:\S+)*)\s*$`)
+
+// HugSQLTags parses HugSQL tags: "-- :name find-users :? :*". The optional
+// command (":?", ":!", ":<!", ":i!" or their long forms) and result (":1",
+// ":*", ":n", ":raw" or their long forms) are mapped to the closest Command.
+func HugSQLTags(line string) (Tag, bool) {
+	matches := hugSQLTagRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return Tag{}, false
+	}
+
+	var hugCommand, hugResult string
+	for _, field := range strings.Fields(matches[2]) {
+		switch field {
+		case ":?", ":query", ":!", ":execute", ":<!", ":returning-execute", ":i!", ":insert":
+			hugCommand = field
+		case ":1", ":one", ":*", ":many", ":n", ":affected", ":raw":
+			hugResult = field
+		default:
+			// Unknown flags are kept so that the scanner reports them.
+			return Tag{Name: matches[1], Command: Command(field)}, true
+		}
+	}
+
+	tag := Tag{Name: matches[1]}
+	switch hugResult {
+	case ":1", ":one":
+		tag.Command = CommandOne
+	case ":*", ":many":
+		tag.Command = CommandMany
+	case ":n", ":affected":
+		tag.Command = CommandExecRows
+	default:
+		switch hugCommand {
+		case ":!", ":execute":
+			tag.Command = CommandExec
+		case ":<!", ":returning-execute":
+			tag.Command = CommandOne
+		case ":i!", ":insert":
+			tag.Command = CommandExecResult
+		}
+	}
+	return tag, true
+}
@@ -0,0 +1,98 @@
+package dotsql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagParsers(t *testing.T) {
+	var tests = []struct {
+		parser TagParser
+		line   string
+		want   Tag
+		ok     bool
+	}{
+		{DefaultTags, "-- name: GetUser :one", Tag{Name: "GetUser", Command: CommandOne}, true},
+		{DefaultTags, "-- :name get-user :? :1", Tag{}, false},
+		{DefaultTags, "/* name: find-users */", Tag{Name: "find-users"}, true},
+		{DefaultTags, "  /*name:GetUser :one*/  ", Tag{Name: "GetUser", Command: CommandOne}, true},
+		{DefaultTags, "/* name: GetUser :one, timeout: 1s, readonly: true */", Tag{
+			Name:     "GetUser",
+			Command:  CommandOne,
+			Metadata: map[string]string{"timeout": "1s", "readonly": "true"},
+		}, true},
+		{DefaultTags, "/* name: */", Tag{}, false},
+		{DefaultTags, "/* name: find-users */ SELECT 1", Tag{}, false},
+		{DefaultTags, "/* some comment */", Tag{}, false},
+
+		{YesqlTags, "-- name: find-users", Tag{Name: "find-users"}, true},
+		{YesqlTags, "-- name: save-user!", Tag{Name: "save-user", Command: CommandExec}, true},
+		{YesqlTags, "--name: create-user<!", Tag{Name: "create-user", Command: CommandExecResult}, true},
+		{YesqlTags, "-- Some Comment", Tag{}, false},
+
+		{HugSQLTags, "-- :name get-user :? :1", Tag{Name: "get-user", Command: CommandOne}, true},
+		{HugSQLTags, "-- :name list-users :? :*", Tag{Name: "list-users", Command: CommandMany}, true},
+		{HugSQLTags, "-- :name list-users :query :many", Tag{Name: "list-users", Command: CommandMany}, true},
+		{HugSQLTags, "-- :name delete-user :! :n", Tag{Name: "delete-user", Command: CommandExecRows}, true},
+		{HugSQLTags, "-- :name create-table :!", Tag{Name: "create-table", Command: CommandExec}, true},
+		{HugSQLTags, "-- :name insert-user :<!", Tag{Name: "insert-user", Command: CommandOne}, true},
+		{HugSQLTags, "-- :name insert-user :i!", Tag{Name: "insert-user", Command: CommandExecResult}, true},
+		{HugSQLTags, "-- :name raw-query", Tag{Name: "raw-query"}, true},
+		{HugSQLTags, "-- :name bad :x", Tag{Name: "bad", Command: Command(":x")}, true},
+		{HugSQLTags, "-- :doc Gets a user", Tag{}, false},
+		{HugSQLTags, "-- name: get-user", Tag{}, false},
+	}
+
+	for _, c := range tests {
+		got, ok := c.parser(c.line)
+		if !reflect.DeepEqual(got, c.want) || ok != c.ok {
+			t.Errorf("parsing '%s' == (%+v, %v), expect (%+v, %v)", c.line, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestLoadWithTagParser(t *testing.T) {
+	dot, err := LoadFromString(`
+-- :name get-user :? :1
+-- :doc Gets a user by id
+SELECT * FROM users WHERE id = :id
+
+-- :name delete-users :! :n
+DELETE FROM users
+`, WithTagParser(HugSQLTags))
+	failIfError(t, err)
+
+	if len(dot.QueryMap()) != 2 {
+		t.Errorf("QueryMap() len (%d) differ from expected (%d)", len(dot.QueryMap()), 2)
+	}
+	if query, _ := dot.Lookup("delete-users"); query.Command != CommandExecRows {
+		t.Errorf("Lookup().Command == %q, expected %q", query.Command, CommandExecRows)
+	}
+
+	_, err = LoadFromString("-- :name bad :x\nSELECT 1", WithTagParser(HugSQLTags))
+	failIfNotError(t, err)
+}
+
+func TestLoadBlockCommentTags(t *testing.T) {
+	dot, err := LoadFromString(`
+/* name: find-users, timeout: 1s */
+-- readonly: true
+SELECT * FROM users
+
+/* name: DeleteUsers :exec */
+DELETE FROM users
+`)
+	failIfError(t, err)
+
+	query, _ := dot.Lookup("find-users")
+	expectedMetadata := map[string]string{"timeout": "1s", "readonly": "true"}
+	if !reflect.DeepEqual(query.Metadata, expectedMetadata) {
+		t.Errorf("Lookup().Metadata == %v, expected %v", query.Metadata, expectedMetadata)
+	}
+	if got := extractTemplate(t, *dot, "find-users"); got != "SELECT * FROM users" {
+		t.Errorf("Raw() == '%s', expected '%s'", got, "SELECT * FROM users")
+	}
+	if query, _ := dot.Lookup("DeleteUsers"); query.Command != CommandExec {
+		t.Errorf("Lookup().Command == %q, expected %q", query.Command, CommandExec)
+	}
+}